@@ -0,0 +1,324 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable with the path of an optional YAML config file.
+// Environment variables override the values from the file.
+const FileEnv = "CONFIG_FILE"
+
+type Config struct {
+	Server     ServerConfig     `yaml:"server"`
+	Mongo      MongoConfig      `yaml:"mongo"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	LogLevel   string           `yaml:"logLevel"`
+	Encounters EncountersConfig `yaml:"encounters"`
+	Features   FeaturesConfig   `yaml:"features"`
+}
+
+type ServerConfig struct {
+	Port         int           `yaml:"port"`
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout"`
+	// ShutdownTimeout is how long in-flight requests may take to finish after a shutdown signal.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// ReadinessTimeout bounds each dependency check of /readyz.
+	ReadinessTimeout time.Duration `yaml:"readinessTimeout"`
+}
+
+type MongoConfig struct {
+	URI            string        `yaml:"uri"`
+	Database       string        `yaml:"database"`
+	ConnectTimeout time.Duration `yaml:"connectTimeout"`
+}
+
+// Tracing exporters.
+const (
+	TracerOTLPGRPC = "otlp-grpc"
+	TracerOTLPHTTP = "otlp-http"
+	TracerFile     = "file"
+	TracerStdout   = "stdout"
+	TracerNone     = "none"
+)
+
+type TracingConfig struct {
+	// Exporter is one of TracerOTLPGRPC, TracerOTLPHTTP, TracerFile, TracerStdout or TracerNone.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the host:port of the OTLP collector, usually 4317 for gRPC and 4318 for HTTP.
+	Endpoint string `yaml:"endpoint"`
+	// Insecure sends spans to the collector without TLS.
+	Insecure bool   `yaml:"insecure"`
+	File     string `yaml:"file"`
+	// SampleRatio is the fraction of new traces that are recorded, from 0 to 1.
+	SampleRatio float64 `yaml:"sampleRatio"`
+	// ParentBased follows the sampling decision of the caller for requests that continue its trace.
+	ParentBased bool `yaml:"parentBased"`
+	// Environment is reported as the deployment.environment resource attribute.
+	Environment string `yaml:"environment"`
+}
+
+type EncountersConfig struct {
+	// ActivationRadius is in meters.
+	ActivationRadius   float64       `yaml:"activationRadius"`
+	TrashPurgeInterval time.Duration `yaml:"trashPurgeInterval"`
+	TrashRetention     time.Duration `yaml:"trashRetention"`
+}
+
+type FeaturesConfig struct {
+	// PurgeJob periodically removes encounters that have been in the trash longer than TrashRetention.
+	PurgeJob bool `yaml:"purgeJob"`
+	// StaticFiles serves StaticDir on every path not taken by the API.
+	StaticFiles bool   `yaml:"staticFiles"`
+	StaticDir   string `yaml:"staticDir"`
+}
+
+// Log levels, from the most verbose.
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// Default returns the configuration used for everything not set in the file or environment.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:             4000,
+			ReadTimeout:      15 * time.Second,
+			WriteTimeout:     30 * time.Second,
+			IdleTimeout:      60 * time.Second,
+			ShutdownTimeout:  20 * time.Second,
+			ReadinessTimeout: 2 * time.Second,
+		},
+		Mongo: MongoConfig{
+			URI:            "mongodb://mongo:27017/?replicaSet=rs0",
+			Database:       "SOAencounters",
+			ConnectTimeout: 10 * time.Second,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracerOTLPGRPC,
+			Endpoint:    "jaeger:4317",
+			Insecure:    true,
+			File:        "traces.json",
+			SampleRatio: 1,
+			ParentBased: true,
+			Environment: "development",
+		},
+		LogLevel: "info",
+		Encounters: EncountersConfig{
+			ActivationRadius:   100,
+			TrashPurgeInterval: time.Hour,
+			TrashRetention:     30 * 24 * time.Hour,
+		},
+		Features: FeaturesConfig{
+			PurgeJob:    true,
+			StaticFiles: true,
+			StaticDir:   "./static",
+		},
+	}
+}
+
+// Load reads the configuration from the defaults, the file named by FileEnv and the
+// environment, in that order, and validates it. All problems are reported together.
+func Load() (Config, error) {
+	config := Default()
+
+	if path := os.Getenv(FileEnv); path != "" {
+		if err := config.loadFile(path); err != nil {
+			return config, err
+		}
+	}
+
+	env := envLoader{}
+	env.int("PORT", &config.Server.Port)
+	env.duration("SERVER_READ_TIMEOUT", &config.Server.ReadTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &config.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &config.Server.IdleTimeout)
+	env.duration("SERVER_SHUTDOWN_TIMEOUT", &config.Server.ShutdownTimeout)
+	env.duration("READINESS_TIMEOUT", &config.Server.ReadinessTimeout)
+	env.string("MONGO_URI", &config.Mongo.URI)
+	env.string("MONGO_DATABASE", &config.Mongo.Database)
+	env.duration("MONGO_CONNECT_TIMEOUT", &config.Mongo.ConnectTimeout)
+	env.string("TRACING_EXPORTER", &config.Tracing.Exporter)
+	env.string("TRACING_ENDPOINT", &config.Tracing.Endpoint)
+	env.bool("TRACING_INSECURE", &config.Tracing.Insecure)
+	env.string("TRACING_FILE", &config.Tracing.File)
+	env.float("TRACING_SAMPLE_RATIO", &config.Tracing.SampleRatio)
+	env.bool("TRACING_PARENT_BASED", &config.Tracing.ParentBased)
+	env.string("DEPLOYMENT_ENVIRONMENT", &config.Tracing.Environment)
+	env.string("LOG_LEVEL", &config.LogLevel)
+	env.float("ACTIVATION_RADIUS_METERS", &config.Encounters.ActivationRadius)
+	env.duration("TRASH_PURGE_INTERVAL", &config.Encounters.TrashPurgeInterval)
+	env.duration("TRASH_RETENTION", &config.Encounters.TrashRetention)
+	env.bool("FEATURE_PURGE_JOB", &config.Features.PurgeJob)
+	env.bool("FEATURE_STATIC_FILES", &config.Features.StaticFiles)
+	env.string("STATIC_DIR", &config.Features.StaticDir)
+
+	config.Tracing.Exporter = strings.ToLower(config.Tracing.Exporter)
+	config.LogLevel = strings.ToLower(config.LogLevel)
+
+	errs := append(env.errs, config.Validate())
+	return config, errors.Join(errs...)
+}
+
+func (config *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	// Greska u kljucu u fajlu inace prodje neprimeceno
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every setting that is missing or out of range.
+func (config *Config) Validate() error {
+	var errs []error
+	invalid := func(setting string, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", setting, fmt.Sprintf(format, args...)))
+	}
+
+	if config.Server.Port < 1 || config.Server.Port > 65535 {
+		invalid("server.port", "%d is not a TCP port", config.Server.Port)
+	}
+	durations := []struct {
+		setting string
+		value   time.Duration
+	}{
+		{"server.readTimeout", config.Server.ReadTimeout},
+		{"server.writeTimeout", config.Server.WriteTimeout},
+		{"server.idleTimeout", config.Server.IdleTimeout},
+		{"server.shutdownTimeout", config.Server.ShutdownTimeout},
+		{"server.readinessTimeout", config.Server.ReadinessTimeout},
+		{"mongo.connectTimeout", config.Mongo.ConnectTimeout},
+		{"encounters.trashPurgeInterval", config.Encounters.TrashPurgeInterval},
+		{"encounters.trashRetention", config.Encounters.TrashRetention},
+	}
+	for _, duration := range durations {
+		if duration.value <= 0 {
+			invalid(duration.setting, "must be positive, got %s", duration.value)
+		}
+	}
+
+	if !strings.HasPrefix(config.Mongo.URI, "mongodb://") && !strings.HasPrefix(config.Mongo.URI, "mongodb+srv://") {
+		invalid("mongo.uri", "%q must start with mongodb:// or mongodb+srv://", config.Mongo.URI)
+	}
+	if config.Mongo.Database == "" {
+		invalid("mongo.database", "is required")
+	}
+
+	switch config.Tracing.Exporter {
+	case TracerOTLPGRPC, TracerOTLPHTTP:
+		if config.Tracing.Endpoint == "" {
+			invalid("tracing.endpoint", "is required by the %s exporter", config.Tracing.Exporter)
+		}
+	case TracerFile:
+		if config.Tracing.File == "" {
+			invalid("tracing.file", "is required by the file exporter")
+		}
+	case TracerStdout, TracerNone:
+	case "jaeger":
+		// Jaeger prima OTLP na portu 4317
+		invalid("tracing.exporter", "the jaeger exporter was removed, use otlp-grpc with the collector at jaeger:4317")
+	default:
+		invalid("tracing.exporter", "%q is not one of otlp-grpc, otlp-http, file, stdout, none", config.Tracing.Exporter)
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		invalid("tracing.sampleRatio", "must be between 0 and 1, got %g", config.Tracing.SampleRatio)
+	}
+
+	if !isLogLevel(config.LogLevel) {
+		invalid("logLevel", "%q is not one of %s", config.LogLevel, strings.Join(LogLevels, ", "))
+	}
+
+	if config.Encounters.ActivationRadius <= 0 {
+		invalid("encounters.activationRadius", "must be positive, got %g", config.Encounters.ActivationRadius)
+	}
+	if config.Features.StaticFiles && config.Features.StaticDir == "" {
+		invalid("features.staticDir", "is required when static files are served")
+	}
+
+	return errors.Join(errs...)
+}
+
+func isLogLevel(level string) bool {
+	for _, l := range LogLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// envLoader overrides settings with the environment variables that are set and
+// collects the values it cannot parse.
+type envLoader struct {
+	errs []error
+}
+
+func (env *envLoader) lookup(name string) (string, bool) {
+	value, ok := os.LookupEnv(name)
+	return strings.TrimSpace(value), ok && strings.TrimSpace(value) != ""
+}
+
+func (env *envLoader) string(name string, target *string) {
+	if value, ok := env.lookup(name); ok {
+		*target = value
+	}
+}
+
+func (env *envLoader) int(name string, target *int) {
+	if value, ok := env.lookup(name); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			env.errs = append(env.errs, fmt.Errorf("%s: %q is not an integer", name, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+func (env *envLoader) float(name string, target *float64) {
+	if value, ok := env.lookup(name); ok {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			env.errs = append(env.errs, fmt.Errorf("%s: %q is not a number", name, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+func (env *envLoader) bool(name string, target *bool) {
+	if value, ok := env.lookup(name); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			env.errs = append(env.errs, fmt.Errorf("%s: %q is not true or false", name, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+func (env *envLoader) duration(name string, target *time.Duration) {
+	if value, ok := env.lookup(name); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			env.errs = append(env.errs, fmt.Errorf("%s: %q is not a duration such as 30s or 720h", name, value))
+			return
+		}
+		*target = parsed
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if config != Default() {
+		t.Errorf("Load without file or environment = %+v, want the defaults", config)
+	}
+}
+
+func TestLoadFileAndEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := "server:\n  port: 8080\nmongo:\n  database: fromFile\ntracing:\n  exporter: none\nencounters:\n  trashRetention: 48h\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(FileEnv, path)
+	t.Setenv("MONGO_DATABASE", "fromEnv")
+	t.Setenv("LOG_LEVEL", "WARN")
+	t.Setenv("FEATURE_PURGE_JOB", "false")
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if config.Server.Port != 8080 || config.Tracing.Exporter != TracerNone || config.Encounters.TrashRetention != 48*time.Hour {
+		t.Errorf("file values not loaded: %+v", config)
+	}
+	if config.Mongo.Database != "fromEnv" {
+		t.Errorf("Mongo.Database = %q, the environment must override the file", config.Mongo.Database)
+	}
+	if config.LogLevel != "warn" || config.Features.PurgeJob {
+		t.Errorf("environment values not loaded: %+v", config)
+	}
+	if config.Mongo.URI != Default().Mongo.URI {
+		t.Errorf("Mongo.URI = %q, want the default", config.Mongo.URI)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	t.Setenv("PORT", "http")
+	t.Setenv("SERVER_READ_TIMEOUT", "-1s")
+	t.Setenv("MONGO_URI", "localhost:27017")
+	t.Setenv("TRACING_EXPORTER", "zipkin")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("ACTIVATION_RADIUS_METERS", "0")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load accepted an invalid configuration")
+	}
+	for _, want := range []string{"PORT", "server.readTimeout", "mongo.uri", "tracing.exporter", "logLevel", "encounters.activationRadius"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("mongo:\n  url: mongodb://localhost\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(FileEnv, path)
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "url") {
+		t.Errorf("Load error = %v, want the unknown key reported", err)
+	}
+}
+
+func TestValidateTracerSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		tracing TracingConfig
+		wantErr string
+	}{
+		{"otlp grpc", TracingConfig{Exporter: TracerOTLPGRPC, Endpoint: "jaeger:4317", SampleRatio: 1}, ""},
+		{"otlp http without endpoint", TracingConfig{Exporter: TracerOTLPHTTP}, "tracing.endpoint"},
+		{"removed jaeger exporter", TracingConfig{Exporter: "jaeger"}, "otlp-grpc"},
+		{"sample ratio above one", TracingConfig{Exporter: TracerNone, SampleRatio: 1.5}, "tracing.sampleRatio"},
+		{"file without path", TracingConfig{Exporter: TracerFile}, "tracing.file"},
+		{"stdout", TracingConfig{Exporter: TracerStdout}, ""},
+		{"none", TracingConfig{Exporter: TracerNone}, ""},
+	}
+
+	for _, tt := range tests {
+		config := Default()
+		config.Tracing = tt.tracing
+		err := config.Validate()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: Validate: %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: Validate error = %v, want %s reported", tt.name, err, tt.wantErr)
+		}
+	}
+}
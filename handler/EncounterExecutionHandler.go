@@ -1,7 +1,7 @@
 package handler
 
 import (
-	"database-example/model"
+	"database-example/logging"
 	"database-example/service"
 	"encoding/json"
 	"net/http"
@@ -18,25 +18,26 @@ func (handler *EncounterExecutionHandler) GetExecutionByUser(writer http.Respons
 	vars := mux.Vars(req)
 	userIDStr, ok := vars["userId"]
 	if !ok {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, service.InvalidField("userId", "is required"))
 		return
 	}
 
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, service.InvalidField("userId", "must be an integer"))
 		return
 	}
+	logging.SetUserID(req.Context(), userID)
 
-	encounter, err := handler.EncounterExecutionService.GetExecutionByUser(userID)
+	encounter, err := handler.EncounterExecutionService.GetExecutionByUser(req.Context(), userID)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
 
 	jsonResponse, err := json.Marshal(encounter)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
 
@@ -49,25 +50,26 @@ func (handler *EncounterExecutionHandler) CompleteEncounter(writer http.Response
 	vars := mux.Vars(req)
 	userIDStr, ok := vars["userId"]
 	if !ok {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, service.InvalidField("userId", "is required"))
 		return
 	}
 
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, service.InvalidField("userId", "must be an integer"))
 		return
 	}
+	logging.SetUserID(req.Context(), userID)
 
-	encounter, err := handler.EncounterExecutionService.CompleteEncounter(userID)
+	encounter, err := handler.EncounterExecutionService.CompleteEncounter(req.Context(), userID)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
 
 	jsonResponse, err := json.Marshal(encounter)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
 
@@ -76,74 +78,130 @@ func (handler *EncounterExecutionHandler) CompleteEncounter(writer http.Response
 	writer.Write(jsonResponse)
 }
 
-func (handler *EncounterExecutionHandler) Create(writer http.ResponseWriter, req *http.Request) {
-	var encounter model.EncounterExecution
-	err := json.NewDecoder(req.Body).Decode(&encounter)
+type touristPosition struct {
+	TouristID int     `json:"touristId"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+func (handler *EncounterExecutionHandler) Activate(writer http.ResponseWriter, req *http.Request) {
+	encounterID := mux.Vars(req)["encounterId"]
+
+	var position touristPosition
+	err := decodeJSON(req, &position)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
+	logging.SetUserID(req.Context(), position.TouristID)
 
-	err = handler.EncounterExecutionService.CreateEncounter(&encounter)
+	execution, err := handler.EncounterExecutionService.Activate(req.Context(), encounterID, position.TouristID, position.Latitude, position.Longitude)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
 
+	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusCreated)
+	json.NewEncoder(writer).Encode(execution)
+}
+
+func (handler *EncounterExecutionHandler) CompleteHiddenLocationEncounter(writer http.ResponseWriter, req *http.Request) {
+	hiddenLocationEncounterID := mux.Vars(req)["id"]
+
+	var position touristPosition
+	err := decodeJSON(req, &position)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+	logging.SetUserID(req.Context(), position.TouristID)
+
+	attempt, err := handler.EncounterExecutionService.CompleteHiddenLocationEncounter(req.Context(), hiddenLocationEncounterID, position.TouristID, position.Latitude, position.Longitude)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(encounter)
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(attempt)
 }
 
-func (handler *EncounterExecutionHandler) Update(writer http.ResponseWriter, req *http.Request) {
-	vars := mux.Vars(req)
-	encIdStr, ok := vars["id"]
-	if !ok {
-		writer.WriteHeader(http.StatusBadRequest)
+func (handler *EncounterExecutionHandler) CheckIn(writer http.ResponseWriter, req *http.Request) {
+	socialEncounterID := mux.Vars(req)["id"]
+
+	var position touristPosition
+	err := decodeJSON(req, &position)
+	if err != nil {
+		writeError(writer, req, err)
 		return
 	}
+	logging.SetUserID(req.Context(), position.TouristID)
 
-	encId, err := strconv.Atoi(encIdStr)
+	checkIn, err := handler.EncounterExecutionService.CheckIn(req.Context(), socialEncounterID, position.TouristID, position.Latitude, position.Longitude)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
 
-	var encounter model.EncounterExecution
-	err = json.NewDecoder(req.Body).Decode(&encounter)
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(checkIn)
+}
+
+func (handler *EncounterExecutionHandler) CheckOut(writer http.ResponseWriter, req *http.Request) {
+	socialEncounterID := mux.Vars(req)["id"]
+
+	var position touristPosition
+	err := decodeJSON(req, &position)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
+	logging.SetUserID(req.Context(), position.TouristID)
 
-	err = handler.EncounterExecutionService.UpdateEncounter(encId, &encounter)
+	social, err := handler.EncounterExecutionService.CheckOut(req.Context(), socialEncounterID, position.TouristID)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
 
+	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(social)
+}
+
+func (handler *EncounterExecutionHandler) GetTouristXp(writer http.ResponseWriter, req *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(req)["id"])
+	if err != nil {
+		writeError(writer, req, service.InvalidField("id", "must be an integer"))
+		return
+	}
+	logging.SetUserID(req.Context(), userID)
+
+	touristXp, err := handler.EncounterExecutionService.GetTouristXp(req.Context(), userID)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(encounter)
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(touristXp)
 }
 
 func (handler *EncounterExecutionHandler) Delete(writer http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	encIdStr, ok := vars["id"]
 	if !ok {
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, service.InvalidField("id", "is required"))
 		return
 	}
 
-	encId, err := strconv.Atoi(encIdStr)
+	err := handler.EncounterExecutionService.DeleteEncounter(req.Context(), encIdStr)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	err = handler.EncounterExecutionService.DeleteEncounter(encId)
-	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
 
@@ -151,19 +209,19 @@ func (handler *EncounterExecutionHandler) Delete(writer http.ResponseWriter, req
 }
 
 func (handler *EncounterExecutionHandler) GetAll(writer http.ResponseWriter, req *http.Request) {
-	encounters, err := handler.EncounterExecutionService.GetAllEncounters()
+	encounters, err := handler.EncounterExecutionService.GetAllEncounters(req.Context())
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
 
 	encountersJson, err := json.Marshal(encounters)
 	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
 
-	writer.WriteHeader(http.StatusOK)
 	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
 	writer.Write(encountersJson)
 }
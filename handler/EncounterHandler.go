@@ -1,17 +1,21 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"database-example/logging"
 	"database-example/model"
 	"database-example/service"
 	"encoding/json"
-	"log"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
-	"github.com/mitchellh/mapstructure"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.opentelemetry.io/otel"
 )
 
 type EncounterHandler struct {
@@ -21,34 +25,39 @@ type EncounterHandler struct {
 func (handler *EncounterHandler) Get(writer http.ResponseWriter, req *http.Request) {
 	id := mux.Vars(req)["id"]
 
-	log.Printf("INFO: Request to get encounter with id: %s", id)
+	viewerID, err := viewerIDFromQuery(req)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+
+	encounter, err := handler.EncounterService.GetEncounterById(req.Context(), id, viewerID)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(modifyEncounterJSON(encounter)))
 }
 
 func (handler *EncounterHandler) Create(writer http.ResponseWriter, req *http.Request) {
-	log.Println("INFO: Entered Create Encounter handler")
-	ctx := req.Context()
-	tracer := otel.Tracer("encounters-service")
-	_, span := tracer.Start(ctx, "CreateEncounter")
-	defer span.End()
-
 	var encounter model.Encounter
-	err := json.NewDecoder(req.Body).Decode(&encounter)
+	err := decodeJSON(req, &encounter)
 	if err != nil {
-		log.Printf("ERROR: Failed to parse JSON: %v", err)
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
 
-	createdEncounter, err := handler.EncounterService.Create(&encounter)
+	createdEncounter, err := handler.EncounterService.Create(req.Context(), &encounter)
 	if err != nil {
-		log.Printf("ERROR: Failed to create encounter: %v", err)
-		writer.WriteHeader(http.StatusExpectationFailed)
+		writeError(writer, req, err)
 		return
 	}
-	log.Printf("INFO: Created encounter: %v", createdEncounter)
-	writer.WriteHeader(http.StatusCreated)
+	slog.InfoContext(req.Context(), "Created encounter", "encounter_id", createdEncounter.ID.Hex(), "type", createdEncounter.Type)
 	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
 
 	response := map[string]interface{}{
 		"id":               createdEncounter.ID.Hex(),
@@ -60,328 +69,638 @@ func (handler *EncounterHandler) Create(writer http.ResponseWriter, req *http.Re
 		"latitude":         createdEncounter.Latitude,
 		"longitude":        createdEncounter.Longitude,
 		"shouldBeApproved": createdEncounter.ShouldBeApproved,
+		"authorId":         createdEncounter.AuthorID,
+		"approval":         createdEncounter.Approval,
 	}
-	log.Printf("INFO: Response data: %v", response)
+	slog.DebugContext(req.Context(), "Created encounter payload", "encounter", response)
 	json.NewEncoder(writer).Encode(response)
 }
 
+// CreateWithDetails creates an encounter and its socialEncounter or
+// hiddenLocationEncounter part from a single request.
+func (handler *EncounterHandler) CreateWithDetails(writer http.ResponseWriter, req *http.Request) {
+	var details model.EncounterDetails
+	err := decodeJSON(req, &details)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+
+	createdEncounter, err := handler.EncounterService.CreateWithDetails(req.Context(), &details)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+	slog.InfoContext(req.Context(), "Created encounter", "encounter_id", createdEncounter.ID.Hex(), "type", createdEncounter.Type)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
+	writer.Write([]byte(modifyEncounterJSON(createdEncounter)))
+}
+
 func (handler *EncounterHandler) CreateSocialEncounter(writer http.ResponseWriter, req *http.Request) {
-	log.Println("INFO: Entered Create Social Encounter handler")
 	var encounter model.SocialEncounter
-	err := json.NewDecoder(req.Body).Decode(&encounter)
+	err := decodeJSON(req, &encounter)
 	if err != nil {
-		log.Printf("ERROR: Failed to parse JSON: %v", err)
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
-	err = handler.EncounterService.CreateSocialEncounter(&encounter)
+	err = handler.EncounterService.CreateSocialEncounter(req.Context(), &encounter)
 	if err != nil {
-		log.Printf("ERROR: Failed to create social encounter: %v", err)
-		writer.WriteHeader(http.StatusExpectationFailed)
+		writeError(writer, req, err)
 		return
 	}
-	log.Printf("INFO: Created social encounter: %v", encounter)
-	writer.WriteHeader(http.StatusCreated)
+	slog.InfoContext(req.Context(), "Created social encounter", "social_encounter_id", encounter.ID.Hex(), "encounter_id", encounter.EncounterID)
 	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
 	json.NewEncoder(writer).Encode(encounter)
 }
 
 func (handler *EncounterHandler) CreateHiddenLocationEncounter(writer http.ResponseWriter, req *http.Request) {
-	log.Println("INFO: Entered Create Hidden Location Encounter handler")
 	var encounter model.HiddenLocationEncounter
-	err := json.NewDecoder(req.Body).Decode(&encounter)
+	err := decodeJSON(req, &encounter)
 	if err != nil {
-		log.Printf("ERROR: Failed to parse JSON: %v", err)
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
-	log.Printf("INFO: Parsed encounter data: %v", encounter)
-	err = handler.EncounterService.CreateHiddenLocationEncounter(&encounter)
+	slog.DebugContext(req.Context(), "Parsed hidden location encounter", "encounter", encounter)
+	err = handler.EncounterService.CreateHiddenLocationEncounter(req.Context(), &encounter)
 	if err != nil {
-		log.Printf("ERROR: Failed to create hidden location encounter: %v", err)
-		writer.WriteHeader(http.StatusExpectationFailed)
+		writeError(writer, req, err)
 		return
 	}
-	log.Printf("INFO: Created hidden location encounter: %v", encounter)
-	writer.WriteHeader(http.StatusCreated)
+	slog.InfoContext(req.Context(), "Created hidden location encounter", "hidden_location_encounter_id", encounter.ID.Hex(), "encounter_id", encounter.EncounterID)
 	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
 	json.NewEncoder(writer).Encode(encounter)
 }
 
 func (h *EncounterHandler) GetAllEncounters(w http.ResponseWriter, r *http.Request) {
-	log.Println("INFO: Entered Get All Encounters handler")
-	ctx := r.Context()
-	tracer := otel.Tracer("encounters-service")
-	_, span := tracer.Start(ctx, "GetAllEncounters")
-	defer span.End()
-	encounters, err := h.EncounterService.GetAllEncounters()
+	viewerID, err := viewerIDFromQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	filter, err := encounterFilterFromQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	page, err := pageRequestFromQuery(r)
 	if err != nil {
-		log.Printf("ERROR: Failed to get encounters: %v", err)
-		http.Error(w, "Error getting encounters", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	log.Printf("INFO: Retrieved encounters: %v", encounters)
+	encounters, next, err := h.EncounterService.GetAllEncounters(r.Context(), viewerID, filter, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "Retrieved encounters", "count", len(encounters))
 
 	modifiedJSON := modifyEncountersJSON(encounters)
 
+	setNextCursor(w, next)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(modifiedJSON))
 }
 
-func modifyEncountersJSON(encounters []*model.Encounter) string {
-
-	var modifiedJSON strings.Builder
-	modifiedJSON.WriteString("[")
-	for i, encounter := range encounters {
-		encounterJSON, err := json.Marshal(encounter)
-		if err != nil {
-			log.Printf("Error marshaling encounter %d: %s\n", i, err.Error())
-			continue
+// modifyEncountersJSON marshals the encounters as a JSON array, leaving out any that
+// cannot be marshalled.
+func modifyEncountersJSON[T any](encounters []*T) string {
+	items := make([]string, 0, len(encounters))
+	for _, encounter := range encounters {
+		if encounterJSON := modifyEncounterJSON(encounter); encounterJSON != "" {
+			items = append(items, encounterJSON)
 		}
+	}
+	return "[" + strings.Join(items, ",") + "]"
+}
 
-		encounterJSONString := string(encounterJSON)
-		encounterJSONString = strings.Replace(encounterJSONString, "\"_id\"", "\"id\"", 1)
+// modifyEncounterJSON marshals a single encounter with "_id" renamed to "id".
+func modifyEncounterJSON[T any](encounter *T) string {
+	encounterJSON, err := json.Marshal(encounter)
+	if err != nil {
+		slog.Error("Failed to marshal encounter", "error", err)
+		return ""
+	}
 
-		modifiedJSON.WriteString(encounterJSONString)
+	return strings.Replace(string(encounterJSON), "\"_id\"", "\"id\"", 1)
+}
 
-		if i < len(encounters)-1 {
-			modifiedJSON.WriteString(",")
-		}
+func (h *EncounterHandler) GetNearbyEncounters(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	latitude, err := strconv.ParseFloat(query.Get("lat"), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		writeError(w, r, service.InvalidField("lat", "must be a number from -90 to 90"))
+		return
+	}
+	longitude, err := strconv.ParseFloat(query.Get("lon"), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		writeError(w, r, service.InvalidField("lon", "must be a number from -180 to 180"))
+		return
+	}
+	radius, err := strconv.ParseFloat(query.Get("radius"), 64)
+	if err != nil || radius <= 0 {
+		writeError(w, r, service.InvalidField("radius", "must be a positive number"))
+		return
+	}
+	viewerID, err := viewerIDFromQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
-	modifiedJSON.WriteString("]")
-	return modifiedJSON.String()
-}
 
-func (h *EncounterHandler) GetAllSocialEncounters(w http.ResponseWriter, r *http.Request) {
-	log.Println("INFO: Entered Get All Social Encounters handler")
-	encounters, err := h.EncounterService.GetAllSocialEncounters()
+	encounters, err := h.EncounterService.GetNearbyEncounters(r.Context(), latitude, longitude, radius, viewerID)
 	if err != nil {
-		log.Printf("ERROR: Failed to get social encounters: %v", err)
-		http.Error(w, "Error getting encounters", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	log.Printf("INFO: Retrieved social encounters: %v", encounters)
+	slog.DebugContext(r.Context(), "Retrieved nearby encounters", "count", len(encounters), "radius_m", radius)
 
-	modifiedJSON := modifyEncountersJSONsoc(encounters)
+	modifiedJSON := modifyEncountersJSON(encounters)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(modifiedJSON))
 }
 
-func modifyEncountersJSONsoc(encounters []*model.SocialEncounter) string {
+// viewerIDFromQuery reads the optional userId of the tourist browsing encounters,
+// so their own proposals are listed before approval. Zero means anonymous.
+// The user is also recorded for the request log.
+func viewerIDFromQuery(r *http.Request) (int, error) {
+	userID := r.URL.Query().Get("userId")
+	if userID == "" {
+		return 0, nil
+	}
+	viewerID, err := strconv.Atoi(userID)
+	if err != nil {
+		return 0, service.InvalidField("userId", "must be an integer")
+	}
+	logging.SetUserID(r.Context(), viewerID)
+	return viewerID, nil
+}
 
-	var modifiedJSON strings.Builder
-	modifiedJSON.WriteString("[")
-	for i, encounter := range encounters {
-		encounterJSON, err := json.Marshal(encounter)
-		if err != nil {
-			log.Printf("ERROR: Error marshaling encounter %d: %s\n", i, err.Error())
-			continue
+// encounterFilterFromQuery reads status, type, minXp, maxXp, name and
+// bbox=minLon,minLat,maxLon,maxLat from the query string.
+func encounterFilterFromQuery(r *http.Request) (model.EncounterFilter, error) {
+	query := r.URL.Query()
+	filter := model.EncounterFilter{
+		Status: query.Get("status"),
+		Type:   query.Get("type"),
+		Name:   query.Get("name"),
+	}
+
+	for param, target := range map[string]**int{"minXp": &filter.MinXp, "maxXp": &filter.MaxXp} {
+		if value := query.Get(param); value != "" {
+			xp, err := strconv.Atoi(value)
+			if err != nil {
+				return filter, service.InvalidField(param, "must be an integer")
+			}
+			*target = &xp
 		}
+	}
+
+	if value := query.Get("bbox"); value != "" {
+		parts := strings.Split(value, ",")
+		if len(parts) != 4 {
+			return filter, service.InvalidField("bbox", "must be minLon,minLat,maxLon,maxLat")
+		}
+		var edges [4]float64
+		for i, part := range parts {
+			edge, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return filter, service.InvalidField("bbox", "must be minLon,minLat,maxLon,maxLat")
+			}
+			edges[i] = edge
+		}
+		filter.BoundingBox = &model.BoundingBox{
+			MinLongitude: edges[0],
+			MinLatitude:  edges[1],
+			MaxLongitude: edges[2],
+			MaxLatitude:  edges[3],
+		}
+	}
 
-		encounterJSONString := string(encounterJSON)
-		encounterJSONString = strings.Replace(encounterJSONString, "\"_id\"", "\"id\"", 1)
+	return filter, nil
+}
 
-		modifiedJSON.WriteString(encounterJSONString)
+// pageRequestFromQuery reads sort, order (asc or desc), limit and cursor from the query string.
+func pageRequestFromQuery(r *http.Request) (model.PageRequest, error) {
+	query := r.URL.Query()
+	page := model.PageRequest{
+		SortBy: query.Get("sort"),
+		Cursor: query.Get("cursor"),
+	}
 
-		if i < len(encounters)-1 {
-			modifiedJSON.WriteString(",")
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		return page, service.InvalidField("order", "must be asc or desc")
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return page, service.InvalidField("limit", "must be a positive integer")
 		}
+		page.Limit = limit
 	}
-	modifiedJSON.WriteString("]")
-	return modifiedJSON.String()
+
+	return page, nil
 }
 
-func (h *EncounterHandler) GetAllHiddenLocationEncounters(w http.ResponseWriter, r *http.Request) {
-	log.Println("INFO: Entered Get All Hidden Location Encounters handler")
-	encounters, err := h.EncounterService.GetAllHiddenLocationEncounters()
+// setNextCursor tells the client how to request the following page, if there is one.
+func setNextCursor(w http.ResponseWriter, next string) {
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+}
+
+func (h *EncounterHandler) GetPendingEncounters(w http.ResponseWriter, r *http.Request) {
+	encounters, err := h.EncounterService.GetPendingEncounters(r.Context())
 	if err != nil {
-		log.Printf("ERROR: Failed to get hidden location encounters: %v", err)
-		http.Error(w, "Error getting encounters", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	log.Printf("INFO: Retrieved hidden location encounters: %v", encounters)
+	slog.DebugContext(r.Context(), "Retrieved pending encounters", "count", len(encounters))
 
-	modifiedJSON := modifyEncountersJSONhidd(encounters)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(modifyEncountersJSON(encounters)))
+}
+
+func (h *EncounterHandler) GetEncountersByAuthor(w http.ResponseWriter, r *http.Request) {
+	authorID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, service.InvalidField("id", "must be an integer"))
+		return
+	}
+	logging.SetUserID(r.Context(), authorID)
+
+	encounters, err := h.EncounterService.GetEncountersByAuthor(r.Context(), authorID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(modifiedJSON))
+	w.Write([]byte(modifyEncountersJSON(encounters)))
 }
 
-func modifyEncountersJSONhidd(encounters []*model.HiddenLocationEncounter) string {
+type reviewRequest struct {
+	ReviewerID int    `json:"reviewerId"`
+	Reason     string `json:"reason"`
+}
 
-	var modifiedJSON strings.Builder
-	modifiedJSON.WriteString("[")
-	for i, encounter := range encounters {
-		encounterJSON, err := json.Marshal(encounter)
-		if err != nil {
-			log.Printf("ERROR: Error marshaling encounter %d: %s\n", i, err.Error())
-			continue
-		}
+func (h *EncounterHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, func(encounterID string, review reviewRequest) (*model.Encounter, error) {
+		return h.EncounterService.Approve(r.Context(), encounterID, review.ReviewerID)
+	})
+}
 
-		encounterJSONString := string(encounterJSON)
-		encounterJSONString = strings.Replace(encounterJSONString, "\"_id\"", "\"id\"", 1)
+func (h *EncounterHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, func(encounterID string, review reviewRequest) (*model.Encounter, error) {
+		return h.EncounterService.Reject(r.Context(), encounterID, review.ReviewerID, review.Reason)
+	})
+}
 
-		modifiedJSON.WriteString(encounterJSONString)
+func (h *EncounterHandler) review(w http.ResponseWriter, r *http.Request, decide func(string, reviewRequest) (*model.Encounter, error)) {
+	encounterID := mux.Vars(r)["id"]
 
-		if i < len(encounters)-1 {
-			modifiedJSON.WriteString(",")
-		}
+	var review reviewRequest
+	if err := decodeJSON(r, &review); err != nil {
+		writeError(w, r, err)
+		return
 	}
-	modifiedJSON.WriteString("]")
-	return modifiedJSON.String()
+	logging.SetUserID(r.Context(), review.ReviewerID)
+
+	encounter, err := decide(encounterID, review)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Reviewed encounter", "encounter_id", encounterID, "decision", encounter.Approval.Decision)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(modifyEncounterJSON(encounter)))
+}
+
+func (h *EncounterHandler) GetAllSocialEncounters(w http.ResponseWriter, r *http.Request) {
+	page, err := pageRequestFromQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	viewerID, err := viewerIDFromQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encounters, next, err := h.EncounterService.GetAllSocialEncounters(r.Context(), viewerID, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "Retrieved social encounters", "count", len(encounters))
+
+	modifiedJSON := modifyEncountersJSON(encounters)
+
+	setNextCursor(w, next)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(modifiedJSON))
+}
+
+func (h *EncounterHandler) GetAllHiddenLocationEncounters(w http.ResponseWriter, r *http.Request) {
+	page, err := pageRequestFromQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	viewerID, err := viewerIDFromQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	encounters, next, err := h.EncounterService.GetAllHiddenLocationEncounters(r.Context(), viewerID, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	slog.DebugContext(r.Context(), "Retrieved hidden location encounters", "count", len(encounters))
+
+	modifiedJSON := modifyEncountersJSON(encounters)
+
+	setNextCursor(w, next)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(modifiedJSON))
 }
 
 func (handler *EncounterHandler) Update(writer http.ResponseWriter, req *http.Request) {
 	var encounter model.Encounter
-	log.Println("INFO: Entered Update Encounter handler")
-	// Dekodiranje JSON-a u mapu kao intermedijernu strukturu
-	var encounterMap map[string]interface{}
-	err := json.NewDecoder(req.Body).Decode(&encounterMap)
-	if err != nil {
-		log.Printf("ERROR: Failed to parse JSON: %v", err)
-		writer.WriteHeader(http.StatusBadRequest)
-		return
-	}
-	log.Printf("INFO: Parsed encounter data: %v", encounterMap)
-	// Konverzija ID-a iz stringa u primitive.ObjectID
-	if id, ok := encounterMap["Id"].(string); ok {
-		objID, err := primitive.ObjectIDFromHex(id)
-		if err != nil {
-			log.Printf("ERROR: Failed to convert ID to ObjectID: %v", err)
-			writer.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		encounterMap["Id"] = objID
-		log.Printf("INFO: Converted ID to ObjectID: %v", objID)
+	if err := decodeUpdate(req, &encounter, &encounter.ID); err != nil {
+		writeError(writer, req, err)
+		return
 	}
+	slog.DebugContext(req.Context(), "Parsed encounter", "encounter", encounter)
 
-	// Konvertovanje mape u strukturu
-	err = mapstructure.Decode(encounterMap, &encounter)
+	updated, err := handler.EncounterService.Update(req.Context(), &encounter)
 	if err != nil {
-		log.Printf("ERROR: Failed to decode JSON to struct: %v", err)
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
+	slog.InfoContext(req.Context(), "Updated encounter", "encounter_id", updated.ID.Hex())
 
-	err = handler.EncounterService.Update(&encounter)
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(modifyEncounterJSON(updated)))
+}
+
+// decodeUpdate decodes the body of a PUT into v and sets id to the id in the path.
+// The body may leave out _id, but must not name a different document.
+func decodeUpdate(req *http.Request, v interface{}, id *primitive.ObjectID) error {
+	pathID, err := primitive.ObjectIDFromHex(mux.Vars(req)["id"])
 	if err != nil {
-		log.Printf("ERROR: Failed to update encounter: %v", err)
-		writer.WriteHeader(http.StatusInternalServerError)
+		return service.InvalidField("id", "must be an ObjectId")
+	}
+	if err := decodeJSON(req, v); err != nil {
+		return err
+	}
+	if !id.IsZero() && *id != pathID {
+		return service.InvalidField("_id", "must match the id in the path")
+	}
+	*id = pathID
+	return nil
+}
+
+// MergePatchContentType is the media type of RFC 7386 JSON merge patches.
+const MergePatchContentType = "application/merge-patch+json"
+
+func (handler *EncounterHandler) Patch(writer http.ResponseWriter, req *http.Request) {
+	patch, ok := readMergePatch(writer, req, &model.Encounter{})
+	if !ok {
 		return
 	}
-	log.Printf("INFO: Updated encounter: %v", encounter)
-	writer.WriteHeader(http.StatusOK)
+
+	updated, err := handler.EncounterService.Patch(req.Context(), mux.Vars(req)["id"], patch)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+	slog.InfoContext(req.Context(), "Patched encounter", "encounter_id", updated.ID.Hex())
+
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(encounter)
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(modifyEncounterJSON(updated)))
 }
 
-func (handler *EncounterHandler) UpdateHiddenLocationEncounter(writer http.ResponseWriter, req *http.Request) {
-	var encounter model.HiddenLocationEncounter
-	log.Println("INFO: Entered Update Hidden Location Encounter handler")
-	// Dekodiranje JSON-a u mapu kao intermedijernu strukturu
-	var encounterMap map[string]interface{}
-	err := json.NewDecoder(req.Body).Decode(&encounterMap)
-	if err != nil {
-		log.Printf("ERROR: Failed to parse JSON: %v", err)
-		writer.WriteHeader(http.StatusBadRequest)
-		return
-	}
-	log.Printf("INFO: Parsed hidden location encounter data: %v", encounterMap)
-	// Konverzija ID-a iz stringa u primitive.ObjectID
-	if id, ok := encounterMap["Id"].(string); ok {
-		objID, err := primitive.ObjectIDFromHex(id)
-		if err != nil {
-			log.Printf("ERROR: Failed to convert ID to ObjectID: %v", err)
-			writer.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		encounterMap["Id"] = objID
-		log.Printf("INFO: Converted ID to ObjectID: %v", objID)
+// readMergePatch reads a JSON merge patch for a document like v, checking that its
+// fields exist in v and have the right types. It responds with the problem and
+// returns false if the patch cannot be applied.
+func readMergePatch(writer http.ResponseWriter, req *http.Request, v interface{}) ([]byte, bool) {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != MergePatchContentType {
+		writer.Header().Set("Accept-Patch", MergePatchContentType)
+		writeProblem(writer, req, http.StatusUnsupportedMediaType, "PATCH expects "+MergePatchContentType)
+		return nil, false
 	}
 
-	// Konvertovanje mape u strukturu
-	err = mapstructure.Decode(encounterMap, &encounter)
+	patch, err := io.ReadAll(req.Body)
 	if err != nil {
-		log.Printf("ERROR: Failed to decode JSON to struct: %v", err)
-		writer.WriteHeader(http.StatusBadRequest)
-		return
+		writeError(writer, req, err)
+		return nil, false
+	}
+	slog.DebugContext(req.Context(), "Parsed merge patch", "patch", string(patch))
+	if err := decodeBody(bytes.NewReader(patch), v); err != nil {
+		writeError(writer, req, err)
+		return nil, false
 	}
+	return patch, true
+}
+
+func (handler *EncounterHandler) Activate(writer http.ResponseWriter, req *http.Request) {
+	handler.changeStatus(writer, req, handler.EncounterService.Activate)
+}
+
+func (handler *EncounterHandler) Archive(writer http.ResponseWriter, req *http.Request) {
+	handler.changeStatus(writer, req, handler.EncounterService.Archive)
+}
+
+func (handler *EncounterHandler) changeStatus(writer http.ResponseWriter, req *http.Request, change func(context.Context, string) (*model.Encounter, error)) {
+	encounterID := mux.Vars(req)["id"]
 
-	err = handler.EncounterService.UpdateHiddenLocationEncounter(&encounter)
+	encounter, err := change(req.Context(), encounterID)
 	if err != nil {
-		log.Printf("ERROR: Failed to update hidden location encounter: %v", err)
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
-	log.Printf("INFO: Updated hidden location encounter: %v", encounter)
+	slog.InfoContext(req.Context(), "Changed encounter status", "encounter_id", encounterID, "status", encounter.Status)
+
+	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(modifyEncounterJSON(encounter)))
+}
+
+func (handler *EncounterHandler) UpdateHiddenLocationEncounter(writer http.ResponseWriter, req *http.Request) {
+	var encounter model.HiddenLocationEncounter
+	if err := decodeUpdate(req, &encounter, &encounter.ID); err != nil {
+		writeError(writer, req, err)
+		return
+	}
+	slog.DebugContext(req.Context(), "Parsed hidden location encounter", "encounter", encounter)
+
+	updated, err := handler.EncounterService.UpdateHiddenLocationEncounter(req.Context(), &encounter)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+	slog.InfoContext(req.Context(), "Updated hidden location encounter", "encounter_id", updated.EncounterID)
+
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(encounter)
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(updated)
 }
 
 func (handler *EncounterHandler) UpdateSocialEncounter(writer http.ResponseWriter, req *http.Request) {
 	var encounter model.SocialEncounter
-	log.Println("INFO: Entered Update Social Encounter handler")
-	var encounterMap map[string]interface{}
-	err := json.NewDecoder(req.Body).Decode(&encounterMap)
+	if err := decodeUpdate(req, &encounter, &encounter.ID); err != nil {
+		writeError(writer, req, err)
+		return
+	}
+	slog.DebugContext(req.Context(), "Parsed social encounter", "encounter", encounter)
+
+	updated, err := handler.EncounterService.UpdateSocialEncounter(req.Context(), &encounter)
 	if err != nil {
-		log.Printf("ERROR: Failed to parse JSON: %v", err)
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
-	log.Printf("INFO: Parsed social encounter data: %v", encounterMap)
-	if id, ok := encounterMap["Id"].(string); ok {
-		objID, err := primitive.ObjectIDFromHex(id)
-		if err != nil {
-			log.Printf("ERROR: Failed to convert ID to ObjectID: %v", err)
-			writer.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		encounterMap["Id"] = objID
-		log.Printf("INFO: Converted ID to ObjectID: %v", objID)
+	slog.InfoContext(req.Context(), "Updated social encounter", "encounter_id", updated.EncounterID)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(updated)
+}
+
+func (handler *EncounterHandler) PatchHiddenLocationEncounter(writer http.ResponseWriter, req *http.Request) {
+	patch, ok := readMergePatch(writer, req, &model.HiddenLocationEncounter{})
+	if !ok {
+		return
 	}
 
-	err = mapstructure.Decode(encounterMap, &encounter)
+	updated, err := handler.EncounterService.PatchHiddenLocationEncounter(req.Context(), mux.Vars(req)["id"], patch)
 	if err != nil {
-		log.Printf("ERROR: Failed to decode JSON to struct: %v", err)
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
+	slog.InfoContext(req.Context(), "Patched hidden location encounter", "encounter_id", updated.EncounterID)
 
-	err = handler.EncounterService.UpdateSocialEncounter(&encounter)
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(updated)
+}
+
+func (handler *EncounterHandler) PatchSocialEncounter(writer http.ResponseWriter, req *http.Request) {
+	patch, ok := readMergePatch(writer, req, &model.SocialEncounter{})
+	if !ok {
+		return
+	}
+
+	updated, err := handler.EncounterService.PatchSocialEncounter(req.Context(), mux.Vars(req)["id"], patch)
 	if err != nil {
-		log.Printf("ERROR: Failed to update social encounter: %v", err)
-		writer.WriteHeader(http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
-	log.Printf("INFO: Updated social encounter: %v", encounter)
-	writer.WriteHeader(http.StatusOK)
+	slog.InfoContext(req.Context(), "Patched social encounter", "encounter_id", updated.EncounterID)
+
 	writer.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(writer).Encode(encounter)
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(updated)
 }
 
 func (handler *EncounterHandler) DeleteEncounter(writer http.ResponseWriter, req *http.Request) {
-	log.Println("INFO: Entered Delete Encounter handler")
 	vars := mux.Vars(req)
 	baseEncounterID := vars["baseEncounterId"]
-	log.Printf("INFO: Base Encounter ID to delete: %s", baseEncounterID)
 
-	err := handler.EncounterService.DeleteEncounter(baseEncounterID)
+	// Korisnik koji brise se upisuje u kantu, pa je obavezan
+	userID := req.URL.Query().Get("userId")
+	if userID == "" {
+		writeError(writer, req, service.InvalidField("userId", "is required"))
+		return
+	}
+	deletedBy, err := strconv.Atoi(userID)
+	if err != nil || deletedBy <= 0 {
+		writeError(writer, req, service.InvalidField("userId", "must be a positive integer"))
+		return
+	}
+	logging.SetUserID(req.Context(), deletedBy)
+
+	err = handler.EncounterService.DeleteEncounter(req.Context(), baseEncounterID, deletedBy)
 	if err != nil {
-		log.Printf("ERROR: Error deleting encounter with ID %s: %v", baseEncounterID, err)
-		http.Error(writer, "Error deleting encounter", http.StatusInternalServerError)
+		writeError(writer, req, err)
 		return
 	}
-	log.Printf("INFO: Successfully deleted encounter with ID %s", baseEncounterID)
+	slog.InfoContext(req.Context(), "Moved encounter to the trash", "encounter_id", baseEncounterID)
 
 	// Ako je brisanje uspešno, vraćamo status 204 No Content
 	writer.WriteHeader(http.StatusNoContent)
 }
 
+func (handler *EncounterHandler) RestoreEncounter(writer http.ResponseWriter, req *http.Request) {
+	encounterID := mux.Vars(req)["id"]
+
+	encounter, err := handler.EncounterService.RestoreEncounter(req.Context(), encounterID)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+	slog.InfoContext(req.Context(), "Restored encounter", "encounter_id", encounterID)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(modifyEncounterJSON(encounter)))
+}
+
+// PurgeEncounter permanently removes an encounter that is in the trash and responds
+// with the number of documents removed of each kind.
+func (handler *EncounterHandler) PurgeEncounter(writer http.ResponseWriter, req *http.Request) {
+	encounterID := mux.Vars(req)["id"]
+
+	deletion, err := handler.EncounterService.PurgeEncounter(req.Context(), encounterID)
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+	slog.InfoContext(req.Context(), "Purged encounter", "encounter_id", encounterID,
+		"social_encounters", deletion.SocialEncounters,
+		"hidden_location_encounters", deletion.HiddenLocationEncounters,
+		"executions", deletion.Executions,
+	)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(deletion)
+}
+
+func (handler *EncounterHandler) GetDeletedEncounters(writer http.ResponseWriter, req *http.Request) {
+	encounters, err := handler.EncounterService.GetDeletedEncounters(req.Context())
+	if err != nil {
+		writeError(writer, req, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(modifyEncountersJSON(encounters)))
+}
+
 /*
 
 func (handler *EncounterHandler) GetSocialEncounterId(writer http.ResponseWriter, req *http.Request) {
@@ -477,79 +796,4 @@ func (handler *EncounterHandler) DeleteHiddenLocationEncounter(writer http.Respo
 	// Uspesan odgovor
 	writer.WriteHeader(http.StatusOK)
 }
-
-
-
-// GetHiddenLocationEncounterByEncounterId handles the GET request for getting a hidden location encounter by encounter ID
-func (handler *EncounterHandler) GetHiddenLocationEncounterByEncounterId(w http.ResponseWriter, r *http.Request) {
-	// Extract the encounterId from the URL parameters
-	vars := mux.Vars(r)
-	encounterIdStr := vars["encounterId"]
-
-	// Convert encounterIdStr to int
-	encounterId, err := strconv.Atoi(encounterIdStr)
-	if err != nil {
-		http.Error(w, "Invalid encounterId", http.StatusBadRequest)
-		return
-	}
-
-	// Call the method from the service package to retrieve the hidden location encounter by encounter ID
-	hiddenLocationEncounter, err := handler.EncounterService.GetHiddenLocationEncounterByEncounterId(encounterId)
-	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Convert the response to JSON
-	responseJSON, err := json.Marshal(hiddenLocationEncounter)
-	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Set response headers
-	w.Header().Set("Content-Type", "application/json")
-
-	// Write the JSON response
-	w.Write(responseJSON)
-}
-
-// ODAVDE IDI DALJE U SERVISE I REPO
-func (handler *EncounterHandler) GetEncounterById(w http.ResponseWriter, r *http.Request) {
-	//Ekstrahovanje parametara iz URL-a ili tela zahteva, ako je potrebno
-	//Pozivanje odgovarajuće funkcionalnosti iz servisnog sloja ili repozitorijuma kako bi se dobio traženi susret
-	//Pretvaranje dobijenih podataka u odgovarajući format (npr. JSON) kako bi se poslali nazad klijentu
-	//Slanje odgovora nazad klijentu putem http.ResponseWriter
-
-	//mux: izvlacenje varijabli iz url parametra
-	//encounterId - parametar putanje
-	vars := mux.Vars(r)
-	encounterIdStr := vars["encounterId"] //izvucena vrednost se cuva kao string
-
-	//konvertovanje stringa u int
-	encounterId, err := strconv.Atoi(encounterIdStr)
-	if err != nil {
-		http.Error(w, "Invalid encounterId", http.StatusBadRequest)
-		return
-	}
-
-	//poziv metode servisa da se dobavi encounter na osnovu encounterId
-	encounter, err := handler.EncounterService.GetEncounterById(encounterId)
-	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	//konvertovanje odgovora (encounter) u json - marshal
-	responseJSON, err := json.Marshal(encounter)
-	if err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	//postavlja se Content-Type zaglavlje HTTP odgovora na application/json, što označava da je odgovor JSON
-	w.Header().Set("Content-Type", "application/json")
-
-	//json odgovor se pise u http.ResponseWriter sto ce se proslediti kao odgovor
-	w.Write(responseJSON)
-}*/
+*/
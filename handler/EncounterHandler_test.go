@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"context"
+	"database-example/model"
+	"database-example/repo"
+	"database-example/service"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEncounterHandler_Update(t *testing.T) {
+	encounterService := &service.EncounterService{EncounterRepo: &repo.InMemoryEncounterRepository{Database: repo.NewInMemoryDatabase()}}
+	encounter, err := encounterService.Create(context.Background(), &model.Encounter{Name: "Bridge", Type: model.Misc.String()})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := encounter.ID.Hex()
+	handler := &EncounterHandler{EncounterService: encounterService}
+
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		wantStatus int
+	}{
+		{"updated", id, `{"name": "Bridge", "xpPoints": 25, "status": "Draft", "type": "Misc"}`, http.StatusOK},
+		{"missing", primitive.NewObjectID().Hex(), `{"name": "Bridge", "status": "Draft", "type": "Misc"}`, http.StatusNotFound},
+		{"other id in body", id, `{"_id": "` + primitive.NewObjectID().Hex() + `", "name": "Bridge", "status": "Draft", "type": "Misc"}`, http.StatusBadRequest},
+		{"invalid id", "42", `{}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/encounters/"+tt.id, strings.NewReader(tt.body)), map[string]string{"id": tt.id})
+		recorder := httptest.NewRecorder()
+		handler.Update(recorder, req)
+
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, recorder.Code, tt.wantStatus, recorder.Body)
+		}
+	}
+
+	stored, err := encounterService.GetEncounterById(context.Background(), id, 0)
+	if err != nil {
+		t.Fatalf("GetEncounterById: %v", err)
+	}
+	if stored.XpPoints != 25 {
+		t.Errorf("stored XpPoints = %d, want 25", stored.XpPoints)
+	}
+}
+
+func TestEncounterHandler_UpdateReturnsStoredEncounter(t *testing.T) {
+	encounterService := &service.EncounterService{EncounterRepo: &repo.InMemoryEncounterRepository{Database: repo.NewInMemoryDatabase()}}
+	encounter, err := encounterService.Create(context.Background(), &model.Encounter{Name: "Bridge", Type: model.Misc.String(), AuthorID: 3})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := encounter.ID.Hex()
+	handler := &EncounterHandler{EncounterService: encounterService}
+
+	body := `{"name": "Old bridge", "xpPoints": 10, "status": "draft", "type": "Misc", "authorId": 9}`
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/encounters/"+id, strings.NewReader(body)), map[string]string{"id": id})
+	recorder := httptest.NewRecorder()
+	handler.Update(recorder, req)
+
+	var updated struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Status   string `json:"status"`
+		AuthorID int    `json:"authorId"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&updated); err != nil {
+		t.Fatalf("decoding the response: %v", err)
+	}
+	if updated.ID != id || updated.Name != "Old bridge" || updated.Status != model.Draft.String() || updated.AuthorID != 3 {
+		t.Errorf("response = %+v, want the stored encounter", updated)
+	}
+}
+
+func TestEncounterHandler_Patch(t *testing.T) {
+	encounterService := &service.EncounterService{EncounterRepo: &repo.InMemoryEncounterRepository{Database: repo.NewInMemoryDatabase()}}
+	encounter, err := encounterService.Create(context.Background(), &model.Encounter{Name: "Bridge", XpPoints: 10, Type: model.Misc.String()})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := encounter.ID.Hex()
+	handler := &EncounterHandler{EncounterService: encounterService}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"plain JSON", "application/json", `{"xpPoints": 20}`, http.StatusUnsupportedMediaType},
+		{"unknown field", MergePatchContentType, `{"xp": 20}`, http.StatusBadRequest},
+		{"wrong type", MergePatchContentType, `{"xpPoints": "many"}`, http.StatusBadRequest},
+		{"patched", MergePatchContentType + "; charset=utf-8", `{"xpPoints": 20}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/encounters/"+id, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		recorder := httptest.NewRecorder()
+		handler.Patch(recorder, mux.SetURLVars(req, map[string]string{"id": id}))
+
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, recorder.Code, tt.wantStatus, recorder.Body)
+		}
+	}
+
+	stored, err := encounterService.GetEncounterById(context.Background(), id, 0)
+	if err != nil {
+		t.Fatalf("GetEncounterById: %v", err)
+	}
+	if stored.XpPoints != 20 || stored.Name != "Bridge" {
+		t.Errorf("stored = %+v, want only xpPoints patched", stored)
+	}
+}
+
+func TestEncounterHandler_CreateRespondsWithJSON(t *testing.T) {
+	handler := &EncounterHandler{EncounterService: &service.EncounterService{EncounterRepo: &repo.InMemoryEncounterRepository{Database: repo.NewInMemoryDatabase()}}}
+
+	req := httptest.NewRequest(http.MethodPost, "/encounters", strings.NewReader(`{"name": "Bridge", "type": "Misc"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler.Create(recorder, req)
+
+	// Result ima zaglavlja kakva su bila kad je status poslat
+	response := recorder.Result()
+	if response.StatusCode != http.StatusCreated || response.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("got %d with Content-Type %q, want %d with application/json: %s", response.StatusCode, response.Header.Get("Content-Type"), http.StatusCreated, recorder.Body)
+	}
+}
+
+func TestModifyEncountersJSONSkipsUnmarshallableItems(t *testing.T) {
+	type item struct {
+		ID    int     `json:"_id"`
+		Value float64 `json:"value"`
+	}
+	items := []*item{{ID: 1}, {ID: 2, Value: math.NaN()}, {ID: 3}, {ID: 4, Value: math.Inf(1)}}
+
+	got := modifyEncountersJSON(items)
+	if want := `[{"id":1,"value":0},{"id":3,"value":0}]`; got != want {
+		t.Errorf("modifyEncountersJSON = %s, want %s", got, want)
+	}
+}
+
+func TestEncounterHandler_PurgeEncounterReportsCounts(t *testing.T) {
+	ctx := context.Background()
+	encounterRepo := &repo.InMemoryEncounterRepository{Database: repo.NewInMemoryDatabase()}
+	handler := &EncounterHandler{EncounterService: &service.EncounterService{EncounterRepo: encounterRepo}}
+	details, err := encounterRepo.CreateEncounterWithDetails(ctx, &model.EncounterDetails{
+		Encounter:       model.Encounter{Name: "Square", Status: model.Active.String(), Type: model.Social.String()},
+		SocialEncounter: &model.SocialEncounter{TouristsRequiredForCompletion: 2, DistanceTreshold: 50},
+	})
+	if err != nil {
+		t.Fatalf("CreateEncounterWithDetails: %v", err)
+	}
+	id := details.ID.Hex()
+	if err := encounterRepo.SoftDeleteEncounter(ctx, id, 3); err != nil {
+		t.Fatalf("SoftDeleteEncounter: %v", err)
+	}
+
+	purge := func() *httptest.ResponseRecorder {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/encounters/trash/"+id, nil), map[string]string{"id": id})
+		recorder := httptest.NewRecorder()
+		handler.PurgeEncounter(recorder, req)
+		return recorder
+	}
+
+	recorder := purge()
+	var deletion model.EncounterDeletion
+	if err := json.NewDecoder(recorder.Body).Decode(&deletion); err != nil {
+		t.Fatalf("decoding the response: %v", err)
+	}
+	if want := (model.EncounterDeletion{Encounters: 1, SocialEncounters: 1}); recorder.Code != http.StatusOK || deletion != want {
+		t.Errorf("got %d %+v, want %d %+v", recorder.Code, deletion, http.StatusOK, want)
+	}
+	if recorder := purge(); recorder.Code != http.StatusNotFound {
+		t.Errorf("second purge status = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
+
+func TestEncounterHandler_ApproveChecksReviewer(t *testing.T) {
+	encounterService := &service.EncounterService{EncounterRepo: &repo.InMemoryEncounterRepository{Database: repo.NewInMemoryDatabase()}}
+	proposal, err := encounterService.Create(context.Background(), &model.Encounter{Name: "Proposal", Status: model.Active.String(), Type: model.Misc.String(), ShouldBeApproved: true, AuthorID: 7})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := proposal.ID.Hex()
+	handler := &EncounterHandler{EncounterService: encounterService}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"missing reviewer", `{}`, http.StatusBadRequest},
+		{"negative reviewer", `{"reviewerId": -1}`, http.StatusBadRequest},
+		{"author", `{"reviewerId": 7}`, http.StatusForbidden},
+		{"approved", `{"reviewerId": 1}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/encounters/"+id+"/approve", strings.NewReader(tt.body)), map[string]string{"id": id})
+		recorder := httptest.NewRecorder()
+		handler.Approve(recorder, req)
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, recorder.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestEncounterHandler_DeleteEncounterRequiresUser(t *testing.T) {
+	encounterService := &service.EncounterService{EncounterRepo: &repo.InMemoryEncounterRepository{Database: repo.NewInMemoryDatabase()}}
+	encounter, err := encounterService.Create(context.Background(), &model.Encounter{Name: "Bridge", Type: model.Misc.String()})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := encounter.ID.Hex()
+	handler := &EncounterHandler{EncounterService: encounterService}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"missing user", "", http.StatusBadRequest},
+		{"invalid user", "?userId=abc", http.StatusBadRequest},
+		{"zero user", "?userId=0", http.StatusBadRequest},
+		{"deleted", "?userId=3", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/encounters/deleteEncounter/"+id+tt.query, nil), map[string]string{"baseEncounterId": id})
+		recorder := httptest.NewRecorder()
+		handler.DeleteEncounter(recorder, req)
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, recorder.Code, tt.wantStatus)
+		}
+	}
+
+	deleted, err := encounterService.GetDeletedEncounters(context.Background())
+	if err != nil {
+		t.Fatalf("GetDeletedEncounters: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].DeletedBy != 3 {
+		t.Errorf("trash = %+v, want the encounter deleted by user 3", deleted)
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheck checks one dependency of the service. Check returns optional details
+// that are included in the readiness report.
+type HealthCheck struct {
+	Name string
+	// Critical checks make the instance not ready when they fail.
+	Critical bool
+	Check    func(ctx context.Context) (interface{}, error)
+}
+
+type HealthHandler struct {
+	Checks []HealthCheck
+	// Timeout bounds each check.
+	Timeout time.Duration
+}
+
+type checkResult struct {
+	Status   string      `json:"status"`
+	Critical bool        `json:"critical"`
+	Duration string      `json:"duration"`
+	Error    string      `json:"error,omitempty"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+type readinessReport struct {
+	Status string                  `json:"status"`
+	Checks map[string]*checkResult `json:"checks"`
+}
+
+// Liveness reports that the process is running and serving requests.
+func (handler *HealthHandler) Liveness(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	writer.Write([]byte(`{"status":"alive"}`))
+}
+
+// Readiness runs every check and answers 503 when a critical one fails, so the
+// orchestrator only routes traffic to instances that can serve it.
+func (handler *HealthHandler) Readiness(writer http.ResponseWriter, req *http.Request) {
+	report := readinessReport{Status: "ready", Checks: map[string]*checkResult{}}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range handler.Checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			result := handler.run(req.Context(), check)
+
+			lock.Lock()
+			defer lock.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != "up" && check.Critical {
+				report.Status = "not ready"
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if report.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+
+	jsonResponse, err := json.Marshal(report)
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(status)
+	writer.Write(jsonResponse)
+}
+
+func (handler *HealthHandler) run(ctx context.Context, check HealthCheck) *checkResult {
+	if handler.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, handler.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	details, err := check.Check(ctx)
+	result := &checkResult{
+		Status:   "up",
+		Critical: check.Critical,
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+		Details:  details,
+	}
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	up := func(ctx context.Context) (interface{}, error) { return nil, nil }
+	down := func(ctx context.Context) (interface{}, error) { return nil, errors.New("unreachable") }
+	hangs := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		checks     []HealthCheck
+		wantStatus int
+		wantChecks map[string]string
+	}{
+		{"all up", []HealthCheck{
+			{Name: "mongo", Critical: true, Check: up},
+			{Name: "tracer", Check: up},
+		}, http.StatusOK, map[string]string{"mongo": "up", "tracer": "up"}},
+		{"optional check down", []HealthCheck{
+			{Name: "mongo", Critical: true, Check: up},
+			{Name: "tracer", Check: down},
+		}, http.StatusOK, map[string]string{"mongo": "up", "tracer": "down"}},
+		{"critical check down", []HealthCheck{
+			{Name: "mongo", Critical: true, Check: down},
+			{Name: "tracer", Check: up},
+		}, http.StatusServiceUnavailable, map[string]string{"mongo": "down", "tracer": "up"}},
+		{"critical check times out", []HealthCheck{
+			{Name: "mongo", Critical: true, Check: hangs},
+		}, http.StatusServiceUnavailable, map[string]string{"mongo": "down"}},
+	}
+
+	for _, tt := range tests {
+		handler := &HealthHandler{Checks: tt.checks, Timeout: 50 * time.Millisecond}
+		recorder := httptest.NewRecorder()
+		handler.Readiness(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, recorder.Code, tt.wantStatus)
+		}
+		var report readinessReport
+		if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+			t.Fatalf("%s: decoding report: %v", tt.name, err)
+		}
+		for name, want := range tt.wantChecks {
+			if result := report.Checks[name]; result == nil || result.Status != want {
+				t.Errorf("%s: check %s = %+v, want %s", tt.name, name, result, want)
+			}
+		}
+	}
+}
@@ -3,8 +3,7 @@ package handler
 import (
 	"database-example/model"
 	"database-example/service"
-	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -16,7 +15,7 @@ type StudentHandler struct {
 
 func (handler *StudentHandler) Get(writer http.ResponseWriter, req *http.Request) {
 	id := mux.Vars(req)["id"]
-	log.Printf("Student sa id-em %s", id)
+	slog.DebugContext(req.Context(), "Getting student", "student_id", id)
 	// student, err := handler.StudentService.FindStudent(id)
 	// writer.Header().Set("Content-Type", "application/json")
 	// if err != nil {
@@ -29,18 +28,16 @@ func (handler *StudentHandler) Get(writer http.ResponseWriter, req *http.Request
 
 func (handler *StudentHandler) Create(writer http.ResponseWriter, req *http.Request) {
 	var student model.Student
-	err := json.NewDecoder(req.Body).Decode(&student)
+	err := decodeJSON(req, &student)
 	if err != nil {
-		println("Error while parsing json")
-		writer.WriteHeader(http.StatusBadRequest)
+		writeError(writer, req, err)
 		return
 	}
-	err = handler.StudentService.Create(&student)
+	err = handler.StudentService.Create(req.Context(), &student)
 	if err != nil {
-		println("Error while creating a new student")
-		writer.WriteHeader(http.StatusExpectationFailed)
+		writeError(writer, req, err)
 		return
 	}
-	writer.WriteHeader(http.StatusCreated)
 	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusCreated)
 }
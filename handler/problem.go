@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"database-example/logging"
+	"database-example/service"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body, extended with the invalid fields of
+// the request and the ids under which the request can be found in logs and traces.
+type Problem struct {
+	Type      string               `json:"type"`
+	Title     string               `json:"title"`
+	Status    int                  `json:"status"`
+	Detail    string               `json:"detail,omitempty"`
+	Instance  string               `json:"instance,omitempty"`
+	Errors    []service.FieldError `json:"errors,omitempty"`
+	RequestID string               `json:"requestId,omitempty"`
+	TraceID   string               `json:"traceId,omitempty"`
+}
+
+var kindStatus = map[service.Kind]int{
+	service.NotFound:   http.StatusNotFound,
+	service.Validation: http.StatusBadRequest,
+	service.Conflict:   http.StatusConflict,
+	service.Forbidden:  http.StatusForbidden,
+}
+
+// writeError responds with the problem for err. The details of internal errors are
+// logged but not sent to the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, ok := kindStatus[service.KindOf(err)]
+	if !ok {
+		slog.ErrorContext(r.Context(), "Request failed", "error", err)
+		writeProblem(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	writeProblem(w, r, status, err.Error(), service.FieldsOf(err)...)
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string, fields ...service.FieldError) {
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Errors:    fields,
+		RequestID: logging.RequestID(r.Context()),
+	}
+	if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+		problem.TraceID = spanContext.TraceID().String()
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+const unknownFieldPrefix = "json: unknown field "
+
+// decodeJSON decodes the request body into v and reports a body that cannot be
+// decoded as a Validation error, naming the field when the JSON has the wrong type
+// or a field v does not know.
+func decodeJSON(r *http.Request, v interface{}) error {
+	return decodeBody(r.Body, v)
+}
+
+func decodeBody(body io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return service.InvalidField(typeErr.Field, "must be "+jsonTypeName(typeErr.Type))
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		// encoding/json nema poseban tip greske za nepoznato polje
+		field := strings.Trim(strings.TrimPrefix(err.Error(), unknownFieldPrefix), `"`)
+		return service.InvalidField(field, "is not a known field")
+	case errors.Is(err, io.EOF):
+		return &service.Error{Kind: service.Validation, Message: "request body is empty"}
+	default:
+		return &service.Error{Kind: service.Validation, Message: "request body is not valid JSON: " + err.Error()}
+	}
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// NotFound reports paths that match no route.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, "no resource at this path")
+}
+
+// MethodNotAllowed reports requests to known paths with a method they do not support.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusMethodNotAllowed, r.Method+" is not supported on this path")
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"database-example/logging"
+	"database-example/repo"
+	"database-example/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteErrorMapsKindsToProblems(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail string
+		wantFields []service.FieldError
+	}{
+		{"not found", fmt.Errorf("loading: %w", repo.ErrEncounterNotFound), http.StatusNotFound, "loading: encounter not found", nil},
+		{"validation", service.InvalidField("lat", "must be a number from -90 to 90"), http.StatusBadRequest,
+			"lat must be a number from -90 to 90", []service.FieldError{{Field: "lat", Message: "must be a number from -90 to 90"}}},
+		{"conflict", service.ErrEncounterNotPending, http.StatusConflict, "encounter is not waiting for approval", nil},
+		{"forbidden", &service.TooFarFromEncounterError{Distance: 500, Radius: 100}, http.StatusForbidden,
+			"tourist is 500m away from the encounter, must be within 100m", nil},
+		{"internal", errors.New("connection reset"), http.StatusInternalServerError, "", nil},
+	}
+
+	for _, tt := range tests {
+		respond := logging.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, r, tt.err)
+		}))
+		recorder := httptest.NewRecorder()
+		respond.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/encounters/1", nil))
+
+		if recorder.Code != tt.wantStatus || recorder.Header().Get("Content-Type") != ProblemContentType {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, recorder.Code, recorder.Header().Get("Content-Type"), tt.wantStatus, ProblemContentType)
+		}
+		var problem Problem
+		if err := json.NewDecoder(recorder.Body).Decode(&problem); err != nil {
+			t.Fatalf("%s: decoding problem: %v", tt.name, err)
+		}
+		if problem.Status != tt.wantStatus || problem.Detail != tt.wantDetail || problem.Instance != "/encounters/1" {
+			t.Errorf("%s: problem = %+v", tt.name, problem)
+		}
+		if len(problem.Errors) != len(tt.wantFields) || (len(tt.wantFields) > 0 && problem.Errors[0] != tt.wantFields[0]) {
+			t.Errorf("%s: errors = %v, want %v", tt.name, problem.Errors, tt.wantFields)
+		}
+		if problem.RequestID == "" || problem.RequestID != recorder.Header().Get(logging.RequestIDHeader) {
+			t.Errorf("%s: requestId = %q, want the id of the request", tt.name, problem.RequestID)
+		}
+	}
+}
+
+func TestDecodeJSONNamesTheInvalidField(t *testing.T) {
+	var encounter struct {
+		XpPoints int `json:"xpPoints"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/encounters", strings.NewReader(`{"xpPoints": "many"}`))
+
+	err := decodeJSON(req, &encounter)
+	if service.KindOf(err) != service.Validation {
+		t.Fatalf("decodeJSON error = %v, want a validation error", err)
+	}
+	fields := service.FieldsOf(err)
+	if len(fields) != 1 || fields[0].Field != "xpPoints" || fields[0].Message != "must be a number" {
+		t.Errorf("fields = %v, want xpPoints reported", fields)
+	}
+}
+
+func TestDecodeJSONRejectsUnknownFields(t *testing.T) {
+	var encounter struct {
+		XpPoints int `json:"xpPoints"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/encounters", strings.NewReader(`{"xpPoints": 10, "xp_points": 20}`))
+
+	err := decodeJSON(req, &encounter)
+	fields := service.FieldsOf(err)
+	if service.KindOf(err) != service.Validation || len(fields) != 1 || fields[0].Field != "xp_points" || fields[0].Message != "is not a known field" {
+		t.Errorf("decodeJSON error = %v, fields = %v, want xp_points rejected", err, fields)
+	}
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New returns a logger that writes JSON lines at level or above. Records logged with
+// the context of a request also carry its request id, route, user id and trace.
+func New(w io.Writer, level string) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: ParseLevel(level)})
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// ParseLevel maps one of config.LogLevels to its slog level. Unknown levels are info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// contextHandler adds the fields of the request and the span in the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info := requestInfoFrom(ctx); info != nil {
+		record.AddAttrs(slog.String("request_id", info.id))
+		if info.route != "" {
+			record.AddAttrs(slog.String("route", info.route))
+		}
+		if userID, ok := info.user(); ok {
+			record.AddAttrs(slog.Int("user_id", userID))
+		}
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+type requestInfoKey struct{}
+
+// requestInfo is shared by everything that logs during a request. The user id is
+// only known once a handler has parsed the request, so it is set later.
+type requestInfo struct {
+	id    string
+	route string
+
+	lock      sync.Mutex
+	userID    int
+	hasUserID bool
+}
+
+func (info *requestInfo) user() (int, bool) {
+	info.lock.Lock()
+	defer info.lock.Unlock()
+	return info.userID, info.hasUserID
+}
+
+func requestInfoFrom(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// RequestID returns the id of the request the context belongs to, or "" outside of one.
+func RequestID(ctx context.Context) string {
+	if info := requestInfoFrom(ctx); info != nil {
+		return info.id
+	}
+	return ""
+}
+
+// SetUserID records the user a request acts for, so that it is logged with every
+// record of the request including the final request log.
+func SetUserID(ctx context.Context, userID int) {
+	info := requestInfoFrom(ctx)
+	if info == nil {
+		return
+	}
+	info.lock.Lock()
+	defer info.lock.Unlock()
+	info.userID = userID
+	info.hasUserID = true
+}
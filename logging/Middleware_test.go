@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// captureLogs sends the default logger to a buffer for the duration of the test.
+func captureLogs(t *testing.T, level string) *bytes.Buffer {
+	var buffer bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(New(&buffer, level))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buffer
+}
+
+func logLines(t *testing.T, buffer *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		lines = append(lines, fields)
+	}
+	return lines
+}
+
+func newTestRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/encounters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		SetUserID(r.Context(), 7)
+		slog.DebugContext(r.Context(), "Encounter payload", "encounter", mux.Vars(r)["id"])
+		slog.InfoContext(r.Context(), "Got encounter")
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+	return router
+}
+
+func TestMiddlewareCorrelatesLogsOfARequest(t *testing.T) {
+	logs := captureLogs(t, "info")
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	ctx, span := tracer.Start(context.Background(), "request")
+	defer span.End()
+
+	req := httptest.NewRequest(http.MethodGet, "/encounters/42", nil).WithContext(ctx)
+	req.Header.Set(RequestIDHeader, "caller-id-1")
+	recorder := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(recorder, req)
+
+	if got := recorder.Header().Get(RequestIDHeader); got != "caller-id-1" {
+		t.Errorf("%s = %q, want the caller's id", RequestIDHeader, got)
+	}
+
+	lines := logLines(t, logs)
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines at info, want the handler's and the request's: %v", len(lines), lines)
+	}
+	for _, line := range lines {
+		want := map[string]interface{}{
+			"request_id": "caller-id-1",
+			"route":      "/encounters/{id}",
+			"user_id":    float64(7),
+			"trace_id":   span.SpanContext().TraceID().String(),
+		}
+		for key, value := range want {
+			if line[key] != value {
+				t.Errorf("%s: %s = %v, want %v", line["msg"], key, line[key], value)
+			}
+		}
+	}
+	if request := lines[1]; request["status"] != float64(http.StatusNotFound) || request["latency_ms"] == nil {
+		t.Errorf("request line = %v, want the status and latency", request)
+	}
+}
+
+func TestMiddlewareCreatesRequestIDs(t *testing.T) {
+	captureLogs(t, "info")
+	router := newTestRouter()
+
+	for _, callerID := range []string{"", "bad id\nwith a newline", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/encounters/42", nil)
+		if callerID != "" {
+			req.Header.Set(RequestIDHeader, callerID)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		got := recorder.Header().Get(RequestIDHeader)
+		if got == "" || got == callerID || !isValidRequestID(got) {
+			t.Errorf("caller id %q: %s = %q, want a new id", callerID, RequestIDHeader, got)
+		}
+	}
+}
@@ -2,24 +2,35 @@ package main
 
 import (
 	"context"
+	"database-example/config"
 	"database-example/handler"
+	"database-example/logging"
+	"database-example/metrics"
 	"database-example/repo"
 	"database-example/service"
-	"log"
+	"database-example/tracing"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	"go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
 
 /*
@@ -35,136 +46,280 @@ import (
 		return database
 	}
 */
-var tp *trace.TracerProvider
+var tp *tracing.Provider
 
-func initDB() *mongo.Database {
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
 
-	clientOptions := options.Client().ApplyURI("mongodb://mongo:27017")
+func initDB(cfg config.MongoConfig) *mongo.Client {
+
+	clientOptions := options.Client().
+		ApplyURI(cfg.URI).
+		SetConnectTimeout(cfg.ConnectTimeout).
+		SetMonitor(chainMonitors(otelmongo.NewMonitor(), metrics.NewMongoMonitor()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
 
 	// Connect to MongoDB
-	client, err := mongo.Connect(context.TODO(), clientOptions)
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Fatal(err)
+		fatal("Failed to connect to MongoDB", err)
 	}
 
-	err = client.Ping(context.TODO(), nil)
+	err = client.Ping(ctx, nil)
 	if err != nil {
-		log.Fatal(err)
+		fatal("Failed to connect to MongoDB", err)
 	}
 
-	database := client.Database("SOAencounters")
-	log.Println("Successfully connected to MongoDB")
-	return database
+	slog.Info("Successfully connected to MongoDB", "database", cfg.Database)
+	return client
+}
+
+// chainMonitors lets the client report every command to more than one monitor,
+// here to tracing and to metrics.
+func chainMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, started *event.CommandStartedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Started != nil {
+					monitor.Started(ctx, started)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, succeeded *event.CommandSucceededEvent) {
+			for _, monitor := range monitors {
+				if monitor.Succeeded != nil {
+					monitor.Succeeded(ctx, succeeded)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, failed *event.CommandFailedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Failed != nil {
+					monitor.Failed(ctx, failed)
+				}
+			}
+		},
+	}
 }
 
-func startServer(handlerEnc *handler.EncounterHandler) {
+func newServer(cfg config.Config, handlerEnc *handler.EncounterHandler, handlerExec *handler.EncounterExecutionHandler, handlerHealth *handler.HealthHandler) *http.Server {
 
 	router := mux.NewRouter().StrictSlash(true)
+	router.NotFoundHandler = http.HandlerFunc(handler.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(handler.MethodNotAllowed)
+	router.Use(otelmux.Middleware("encounters-service"))
+	router.Use(logging.Middleware)
+	router.Use(metrics.Middleware)
 
+	router.HandleFunc("/healthz", handlerHealth.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", handlerHealth.Readiness).Methods("GET")
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	router.HandleFunc("/encounters", handlerEnc.CreateWithDetails).Methods("POST")
 	router.HandleFunc("/encounters/create", handlerEnc.Create).Methods("POST")
 	router.HandleFunc("/encounters/createSocialEncounter", handlerEnc.CreateSocialEncounter).Methods("POST")
 	router.HandleFunc("/encounters/createHiddenLocationEncounter", handlerEnc.CreateHiddenLocationEncounter).Methods("POST")
 
 	router.HandleFunc("/encounters", handlerEnc.GetAllEncounters).Methods("GET")
+	router.HandleFunc("/encounters/nearby", handlerEnc.GetNearbyEncounters).Methods("GET")
+	router.HandleFunc("/encounters/pending", handlerEnc.GetPendingEncounters).Methods("GET")
+	router.HandleFunc("/encounters/trash", handlerEnc.GetDeletedEncounters).Methods("GET")
+	router.HandleFunc("/encounters/{id}", handlerEnc.Get).Methods("GET")
+	router.HandleFunc("/tourists/{id}/encounters", handlerEnc.GetEncountersByAuthor).Methods("GET")
 	router.HandleFunc("/hiddenLocationEncounters", handlerEnc.GetAllHiddenLocationEncounters).Methods("GET")
 	router.HandleFunc("/socialEncounters", handlerEnc.GetAllSocialEncounters).Methods("GET")
 
-	router.HandleFunc("/encounters/update", handlerEnc.Update).Methods("PUT")
-	router.HandleFunc("/encounters/updateHiddenLocationEncounter", handlerEnc.UpdateHiddenLocationEncounter).Methods("PUT")
-	router.HandleFunc("/encounters/updateSocialEncounter", handlerEnc.UpdateSocialEncounter).Methods("PUT")
+	router.HandleFunc("/encounters/{id}", handlerEnc.Update).Methods("PUT")
+	router.HandleFunc("/hiddenLocationEncounters/{id}", handlerEnc.UpdateHiddenLocationEncounter).Methods("PUT")
+	router.HandleFunc("/socialEncounters/{id}", handlerEnc.UpdateSocialEncounter).Methods("PUT")
+	router.HandleFunc("/encounters/{id}", handlerEnc.Patch).Methods("PATCH")
+	router.HandleFunc("/hiddenLocationEncounters/{id}", handlerEnc.PatchHiddenLocationEncounter).Methods("PATCH")
+	router.HandleFunc("/socialEncounters/{id}", handlerEnc.PatchSocialEncounter).Methods("PATCH")
+
+	router.HandleFunc("/encounters/{id}/activate", handlerEnc.Activate).Methods("POST")
+	router.HandleFunc("/encounters/{id}/archive", handlerEnc.Archive).Methods("POST")
+	router.HandleFunc("/encounters/{id}/approve", handlerEnc.Approve).Methods("POST")
+	router.HandleFunc("/encounters/{id}/reject", handlerEnc.Reject).Methods("POST")
 
 	router.HandleFunc("/encounters/deleteEncounter/{baseEncounterId}", handlerEnc.DeleteEncounter).Methods("DELETE")
+	router.HandleFunc("/encounters/{id}/restore", handlerEnc.RestoreEncounter).Methods("POST")
+	router.HandleFunc("/encounters/trash/{id}", handlerEnc.PurgeEncounter).Methods("DELETE")
+
+	router.HandleFunc("/encounterExecutions/activate/{encounterId}", handlerExec.Activate).Methods("POST")
+	router.HandleFunc("/encounterExecutions", handlerExec.GetAll).Methods("GET")
+	router.HandleFunc("/encounterExecutions/getByUser/{userId}", handlerExec.GetExecutionByUser).Methods("GET")
+	router.HandleFunc("/encounterExecutions/complete/{userId}", handlerExec.CompleteEncounter).Methods("PUT")
+	router.HandleFunc("/hiddenLocationEncounters/{id}/complete", handlerExec.CompleteHiddenLocationEncounter).Methods("POST")
+	router.HandleFunc("/socialEncounters/{id}/checkin", handlerExec.CheckIn).Methods("POST")
+	router.HandleFunc("/socialEncounters/{id}/checkout", handlerExec.CheckOut).Methods("POST")
+	router.HandleFunc("/tourists/{id}/xp", handlerExec.GetTouristXp).Methods("GET")
+	router.HandleFunc("/encounterExecutions/delete/{id}", handlerExec.Delete).Methods("DELETE")
+
+	if cfg.Features.StaticFiles {
+		router.PathPrefix("/").Handler(http.FileServer(http.Dir(cfg.Features.StaticDir)))
+	}
 
-	router.PathPrefix("/").Handler(http.FileServer(http.Dir("./static")))
+	server := &http.Server{
+		Addr:         ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	return server
+}
+
+// serve runs the server until ctx is done, then stops accepting connections and waits
+// up to timeout for in-flight requests to finish.
+func serve(ctx context.Context, server *http.Server, timeout time.Duration) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "addr", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
 
-	println("Server starting")
-	log.Fatal(http.ListenAndServe(":4000", router))
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
 
+	slog.Info("Shutting down, draining requests", "timeout", timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
 }
 
 func main() {
 
-	var err error
-	tp, err = initTracer()
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal(err)
+		fatal("Invalid configuration", err)
+	}
+	// I poruke paketa log i biblioteka prolaze kroz ovaj logger
+	slog.SetDefault(logging.New(os.Stderr, cfg.LogLevel))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	tp, err = tracing.NewProvider(cfg.Tracing, version)
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
 	}
-	defer func() {
-		if err := tp.Shutdown(context.Background()); err != nil {
-			log.Printf("Error shutting down tracer provider: %v", err)
-		}
-	}()
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	database := initDB()
-	if database == nil {
-		print("FAILED TO CONNECT TO DB")
-		return
+	client := initDB(cfg.Mongo)
+	encounterRepo := &repo.MongoEncounterRepository{DatabaseConnection: client, DatabaseName: cfg.Mongo.Database}
+	if err := encounterRepo.RenameLegacyFields(ctx); err != nil {
+		fatal("Failed to rename the legacy encounter fields", err)
+	}
+	if err := encounterRepo.EnsureLocationIndex(ctx); err != nil {
+		fatal("Failed to create the location index", err)
 	}
-	client := database.Client()
-	encounterRepo := &repo.EncounterRepository{DatabaseConnection: client}
-	//encounterRepo := &repo.EncounterRepository{DatabaseConnection: database}
+	//encounterRepo := &repo.MongoEncounterRepository{DatabaseConnection: database}
 	encounterService := &service.EncounterService{EncounterRepo: encounterRepo}
 	encounterHandler := &handler.EncounterHandler{EncounterService: encounterService}
-	/*
-		encounterExecutionRepo := &repo.EncounterExecutionRepository{DatabaseConnection: database}
-		encounterExecutionService := &service.EncounterExecutionService{EncounterExecutionRepo: encounterExecutionRepo}
-		encounterExecutionHandler := &handler.EncounterExecutionHandler{EncounterExecutionService: encounterExecutionService}
-	*/
-	startServer(encounterHandler)
-}
 
-func initTracer() (*trace.TracerProvider, error) {
-	url := "http://jaeger:14268/api/traces"
-	if len(url) > 0 {
-		return initJaegerTracer(url)
+	purgeJobDone := make(chan struct{})
+	if cfg.Features.PurgeJob {
+		go func() {
+			defer close(purgeJobDone)
+			encounterService.RunPurgeJob(ctx, cfg.Encounters.TrashPurgeInterval, cfg.Encounters.TrashRetention)
+		}()
 	} else {
-		return initFileTracer()
+		close(purgeJobDone)
 	}
-}
 
-func initFileTracer() (*trace.TracerProvider, error) {
-	log.Println("Initializing tracing to traces.json")
-	f, err := os.Create("traces.json")
-	if err != nil {
-		return nil, err
+	encounterExecutionRepo := &repo.MongoEncounterExecutionRepository{DatabaseConnection: client, DatabaseName: cfg.Mongo.Database}
+	if err := encounterExecutionRepo.EnsureIndexes(ctx); err != nil {
+		fatal("Failed to create the encounter execution indexes", err)
 	}
-	exporter, err := stdouttrace.New(
-		stdouttrace.WithWriter(f),
-		stdouttrace.WithPrettyPrint(),
-	)
-	if err != nil {
-		return nil, err
+	xpLedgerRepo := &repo.MongoXpLedgerRepository{DatabaseConnection: client, DatabaseName: cfg.Mongo.Database}
+	if err := xpLedgerRepo.EnsureIndexes(ctx); err != nil {
+		fatal("Failed to create the XP ledger indexes", err)
+	}
+	encounterExecutionService := &service.EncounterExecutionService{
+		EncounterExecutionRepo: encounterExecutionRepo,
+		EncounterRepo:          encounterRepo,
+		XpLedgerRepo:           xpLedgerRepo,
+		ActivationRadius:       cfg.Encounters.ActivationRadius,
+	}
+	encounterExecutionHandler := &handler.EncounterExecutionHandler{EncounterExecutionService: encounterExecutionService}
+
+	healthHandler := &handler.HealthHandler{Checks: healthChecks(cfg, client), Timeout: cfg.Server.ReadinessTimeout}
+
+	server := newServer(cfg, encounterHandler, encounterExecutionHandler, healthHandler)
+	if err := serve(ctx, server, cfg.Server.ShutdownTimeout); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Server stopped", "error", err)
+	}
+
+	// Redosled je bitan: prvo se zavrse zahtevi i posao ciscenja koji koriste bazu i tracer
+	stop()
+	<-purgeJobDone
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := tp.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to flush the tracer provider", "error", err)
+	}
+	if err := client.Disconnect(shutdownCtx); err != nil {
+		slog.Error("Failed to disconnect from MongoDB", "error", err)
 	}
-	return trace.NewTracerProvider(
-		trace.WithBatcher(exporter),
-		trace.WithSampler(trace.AlwaysSample()),
-	), nil
+	slog.Info("Server stopped")
 }
 
-func initJaegerTracer(url string) (*trace.TracerProvider, error) {
-	log.Printf("Initializing tracing to jaeger at %s\n", url)
-	exporter, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(url)))
-	if err != nil {
-		return nil, err
-	}
-	return trace.NewTracerProvider(
-		trace.WithBatcher(exporter),
-		trace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("encounters-service"),
-		)),
-	), nil
+// healthChecks are the dependencies reported by /readyz. Tracing is reported but
+// does not make the instance unready.
+func healthChecks(cfg config.Config, client *mongo.Client) []handler.HealthCheck {
+	return []handler.HealthCheck{
+		{
+			Name:     "mongo",
+			Critical: true,
+			Check: func(ctx context.Context) (interface{}, error) {
+				return nil, client.Ping(ctx, readpref.Primary())
+			},
+		},
+		{
+			Name:     "indexes",
+			Critical: true,
+			Check: func(ctx context.Context) (interface{}, error) {
+				missing, err := repo.MissingMongoIndexes(ctx, client, cfg.Mongo.Database)
+				if err != nil {
+					return nil, err
+				}
+				if len(missing) > 0 {
+					return missing, fmt.Errorf("missing indexes: %s", strings.Join(missing, ", "))
+				}
+				return nil, nil
+			},
+		},
+		{
+			Name: "tracer",
+			Check: func(ctx context.Context) (interface{}, error) {
+				state := tp.ExporterState()
+				if state.LastError != "" {
+					return state, errors.New(state.LastError)
+				}
+				return state, nil
+			},
+		},
+	}
 }
 
-/*
+// fatal logs the error that keeps the service from starting and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
 
-	router.HandleFunc("/encounters/getEncounterById/{encounterId}", handlerEnc.GetEncounterById).Methods("GET")
+/*
 
 	router.HandleFunc("/encounters/getSocialEncounterId/{baseEncounterId}", handlerEnc.GetSocialEncounterId).Methods("GET")
 	router.HandleFunc("/encounters/getHiddenLocationEncounterId/{baseEncounterId}", handlerEnc.GetHiddenLocationEncounterId).Methods("GET")
-	router.HandleFunc("/encounters/getHiddenLocationEncounter/{encounterId}", handlerEnc.GetHiddenLocationEncounterByEncounterId).Methods("GET")
 
 	router.HandleFunc("/encounters/deleteEncounter/{baseEncounterId}", handlerEnc.DeleteEncounter).Methods("DELETE")
 	router.HandleFunc("/encounters/deleteSocialEncounter/{socialEncounterId}", handlerEnc.DeleteSocialEncounter).Methods("DELETE")
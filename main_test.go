@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"database-example/config"
+	"database-example/handler"
+	"database-example/model"
+	"database-example/repo"
+	"database-example/service"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	started := make(chan struct{})
+	server := &http.Server{
+		Addr: address,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("done"))
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, server, 5*time.Second) }()
+
+	response := make(chan string, 1)
+	go func() {
+		for i := 0; i < 50; i++ {
+			resp, err := http.Get("http://" + address)
+			if err != nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			response <- string(body)
+			return
+		}
+		response <- "server did not start"
+	}()
+
+	<-started
+	cancel()
+
+	if got := <-response; got != "done" {
+		t.Errorf("in-flight request got %q, want it to finish", got)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serve: %v", err)
+	}
+	if _, err := http.Get("http://" + address); err == nil {
+		t.Error("server still accepts connections after shutdown")
+	}
+}
+
+func TestServerDoesNotLetClientsCompleteExecutions(t *testing.T) {
+	ctx := context.Background()
+	database := repo.NewInMemoryDatabase()
+	executionService := &service.EncounterExecutionService{
+		EncounterExecutionRepo: &repo.InMemoryEncounterExecutionRepository{Database: database},
+		EncounterRepo:          &repo.InMemoryEncounterRepository{Database: database},
+		XpLedgerRepo:           &repo.InMemoryXpLedgerRepository{Database: database},
+	}
+	encounter, err := executionService.EncounterRepo.CreateEncounter(ctx, &model.Encounter{
+		Name: "Bridge", XpPoints: 25, Status: model.Active.String(), Type: model.Misc.String(), Latitude: 45, Longitude: 19,
+	})
+	if err != nil {
+		t.Fatalf("CreateEncounter: %v", err)
+	}
+	execution, err := executionService.Activate(ctx, encounter.ID.Hex(), 5, 45, 19)
+	if err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	server := newServer(config.Config{},
+		&handler.EncounterHandler{EncounterService: &service.EncounterService{EncounterRepo: executionService.EncounterRepo}},
+		&handler.EncounterExecutionHandler{EncounterExecutionService: executionService},
+		&handler.HealthHandler{})
+
+	body := `{"userId": 5, "encounterID": "` + encounter.ID.Hex() + `", "isCompleted": true}`
+	req := httptest.NewRequest(http.MethodPut, "/encounterExecutions/update/"+execution.ID.Hex(), strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+	stored, err := executionService.GetExecutionByUser(ctx, 5)
+	if err != nil {
+		t.Fatalf("GetExecutionByUser: %v", err)
+	}
+	if stored.IsCompleted {
+		t.Error("execution was completed by a PUT, want it completed only through the completion routes")
+	}
+}
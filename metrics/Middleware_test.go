@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMiddlewareLabelsRequestsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/encounters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("{}"))
+	}).Methods("GET")
+
+	HTTPRequestDuration.Reset()
+	for _, path := range []string{"/encounters/1", "/encounters/2", "/encounters/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	tests := []struct {
+		status string
+		want   uint64
+	}{
+		{"200", 2},
+		{"404", 1},
+	}
+	for _, tt := range tests {
+		var metric dto.Metric
+		histogram := HTTPRequestDuration.WithLabelValues("GET", "/encounters/{id}", tt.status).(prometheus.Histogram)
+		if err := histogram.Write(&metric); err != nil {
+			t.Fatal(err)
+		}
+		if got := metric.GetHistogram().GetSampleCount(); got != tt.want {
+			t.Errorf("requests with status %s = %d, want %d", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// NewMongoMonitor returns a command monitor that records MongoOperationDuration.
+func NewMongoMonitor() *event.CommandMonitor {
+	// Kolekcija je poznata samo u Started dogadjaju
+	var collections sync.Map
+
+	finished := func(requestID int64, operation string, outcome string, seconds float64) {
+		collection, ok := collections.LoadAndDelete(requestID)
+		if !ok {
+			return
+		}
+		MongoOperationDuration.WithLabelValues(collection.(string), operation, outcome).Observe(seconds)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, started *event.CommandStartedEvent) {
+			collections.Store(started.RequestID, commandCollection(started))
+		},
+		Succeeded: func(ctx context.Context, succeeded *event.CommandSucceededEvent) {
+			finished(succeeded.RequestID, succeeded.CommandName, "success", succeeded.Duration.Seconds())
+		},
+		Failed: func(ctx context.Context, failed *event.CommandFailedEvent) {
+			finished(failed.RequestID, failed.CommandName, "error", failed.Duration.Seconds())
+		},
+	}
+}
+
+// commandCollection returns the collection a command runs on, which is the value of
+// its first element for collection commands such as {find: "encounters"}.
+func commandCollection(started *event.CommandStartedEvent) string {
+	elements, err := started.Command.Elements()
+	if err != nil || len(elements) == 0 {
+		return ""
+	}
+	if collection, ok := elements[0].Value().StringValueOK(); ok && elements[0].Key() == started.CommandName {
+		return collection
+	}
+	// Komande nad bazom, kao sto su ping i commitTransaction
+	return ""
+}
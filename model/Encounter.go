@@ -1,6 +1,12 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type EncounterStatus int
 
@@ -10,6 +16,29 @@ const (
 	Active
 )
 
+var encounterStatusNames = map[EncounterStatus]string{
+	Draft:    "Draft",
+	Archived: "Archived",
+	Active:   "Active",
+}
+
+func (status EncounterStatus) String() string {
+	if name, ok := encounterStatusNames[status]; ok {
+		return name
+	}
+	return fmt.Sprintf("EncounterStatus(%d)", int(status))
+}
+
+// ParseEncounterStatus maps the stored status string to its EncounterStatus, ignoring case.
+func ParseEncounterStatus(value string) (EncounterStatus, error) {
+	for status, name := range encounterStatusNames {
+		if strings.EqualFold(name, strings.TrimSpace(value)) {
+			return status, nil
+		}
+	}
+	return Draft, fmt.Errorf("unknown encounter status %q", value)
+}
+
 type EncounterType int
 
 const (
@@ -18,14 +47,86 @@ const (
 	Misc
 )
 
+var encounterTypeNames = map[EncounterType]string{
+	Social:   "Social",
+	Location: "Location",
+	Misc:     "Misc",
+}
+
+func (encounterType EncounterType) String() string {
+	if name, ok := encounterTypeNames[encounterType]; ok {
+		return name
+	}
+	return fmt.Sprintf("EncounterType(%d)", int(encounterType))
+}
+
+// ParseEncounterType maps the stored type string to its EncounterType, ignoring case.
+func ParseEncounterType(value string) (EncounterType, error) {
+	for encounterType, name := range encounterTypeNames {
+		if strings.EqualFold(name, strings.TrimSpace(value)) {
+			return encounterType, nil
+		}
+	}
+	return Misc, fmt.Errorf("unknown encounter type %q", value)
+}
+
+type ApprovalDecision string
+
+const (
+	ApprovalPending  ApprovalDecision = "Pending"
+	ApprovalApproved ApprovalDecision = "Approved"
+	ApprovalRejected ApprovalDecision = "Rejected"
+)
+
+// EncounterApproval records the administrator's review of an encounter
+// created with ShouldBeApproved set.
+type EncounterApproval struct {
+	Decision   ApprovalDecision `json:"decision" bson:"decision"`
+	ReviewerID int              `json:"reviewerId,omitempty" bson:"reviewerId,omitempty"`
+	Reason     string           `json:"reason,omitempty" bson:"reason,omitempty"`
+	ReviewedAt *time.Time       `json:"reviewedAt,omitempty" bson:"reviewedAt,omitempty"`
+}
+
+// Encounter is stored under the same field names it has in JSON. Payloads are
+// checked against the validate tags before they are stored.
 type Encounter struct {
 	ID               primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	Name             string             `json:"name"`
-	Description      string             `json:"description"`
-	XpPoints         int                `json:"xpPoints"`
-	Status           string             `json:"status"`
-	Type             string             `json:"type"`
-	Latitude         float64            `json:"latitude"`
-	Longitude        float64            `json:"longitude"`
-	ShouldBeApproved bool               `json:"shouldBeApproved"`
+	Name             string             `json:"name" bson:"name" validate:"required,max=200"`
+	Description      string             `json:"description" bson:"description" validate:"max=2000"`
+	XpPoints         int                `json:"xpPoints" bson:"xpPoints" validate:"min=0"`
+	Status           string             `json:"status" bson:"status" validate:"omitempty,encounterStatus"`
+	Type             string             `json:"type" bson:"type" validate:"encounterType"`
+	Latitude         float64            `json:"latitude" bson:"latitude" validate:"min=-90,max=90"`
+	Longitude        float64            `json:"longitude" bson:"longitude" validate:"min=-180,max=180"`
+	ShouldBeApproved bool               `json:"shouldBeApproved" bson:"shouldBeApproved"`
+	AuthorID         int                `json:"authorId" bson:"authorId"`
+	Approval         *EncounterApproval `json:"approval,omitempty" bson:"approval,omitempty"`
+	Location         *GeoPoint          `json:"-" bson:"location,omitempty"`
+	DeletedAt        *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy        int                `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
+}
+
+// IsApproved reports whether the encounter may be shown to tourists other than its author.
+func (encounter *Encounter) IsApproved() bool {
+	return !encounter.ShouldBeApproved || (encounter.Approval != nil && encounter.Approval.Decision == ApprovalApproved)
+}
+
+type NearbyEncounter struct {
+	Encounter `bson:",inline"`
+	Distance  float64 `json:"distance" bson:"distance"`
+}
+
+// EncounterDetails is an encounter together with the subtype matching its Type.
+type EncounterDetails struct {
+	Encounter
+	SocialEncounter         *SocialEncounter         `json:"socialEncounter,omitempty"`
+	HiddenLocationEncounter *HiddenLocationEncounter `json:"hiddenLocationEncounter,omitempty"`
+}
+
+// EncounterDeletion counts the documents removed together with an encounter.
+type EncounterDeletion struct {
+	Encounters               int64 `json:"encounters"`
+	SocialEncounters         int64 `json:"socialEncounters"`
+	HiddenLocationEncounters int64 `json:"hiddenLocationEncounters"`
+	Executions               int64 `json:"executions"`
 }
@@ -1,11 +1,24 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type EncounterExecution struct {
-	ID             int       `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
-	UserID         int       `json:"userId"`
-	EncounterID    int       `json:"encounterID"`
-	CompletionTime time.Time `json:"completionTime"`
-	IsCompleted    bool      `json:"isCompleted"`
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID         int                `json:"userId" bson:"userId"`
+	EncounterID    primitive.ObjectID `json:"encounterID" bson:"encounterId"`
+	CompletionTime time.Time          `json:"completionTime" bson:"completionTime"`
+	IsCompleted    bool               `json:"isCompleted" bson:"isCompleted"`
+}
+
+// CompletionAttempt is the outcome of a tourist trying to complete an encounter
+// from their current position. Distances are in meters.
+type CompletionAttempt struct {
+	Completed         bool                `json:"completed"`
+	Distance          float64             `json:"distance"`
+	RemainingDistance float64             `json:"remainingDistance"`
+	Execution         *EncounterExecution `json:"execution,omitempty"`
 }
@@ -0,0 +1,32 @@
+package model
+
+import "math"
+
+// GeoPoint is a GeoJSON point as expected by MongoDB's 2dsphere index.
+// Coordinates are stored in [longitude, latitude] order.
+type GeoPoint struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
+}
+
+func NewGeoPoint(latitude, longitude float64) *GeoPoint {
+	return &GeoPoint{
+		Type:        "Point",
+		Coordinates: []float64{longitude, latitude},
+	}
+}
+
+const earthRadiusInMeters = 6371000
+
+// DistanceInMeters returns the great-circle distance between two points
+// using the haversine formula.
+func DistanceInMeters(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	lat1 := latitude1 * math.Pi / 180
+	lat2 := latitude2 * math.Pi / 180
+	deltaLat := (latitude2 - latitude1) * math.Pi / 180
+	deltaLon := (longitude2 - longitude1) * math.Pi / 180
+
+	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return 2 * earthRadiusInMeters * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -1,12 +1,18 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type HiddenLocationEncounter struct {
 	ID               primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	ImageURL         string             `json:"imageURL"`
-	ImageLatitude    float64            `json:"imageLatitude"`
-	ImageLongitude   float64            `json:"imageLongitude"`
-	DistanceTreshold float64            `json:"distanceTreshold"`
-	EncounterID      string             `json:"encounterId"`
+	ImageURL         string             `json:"imageURL" bson:"imageURL" validate:"required,url"`
+	ImageLatitude    float64            `json:"imageLatitude" bson:"imageLatitude" validate:"min=-90,max=90"`
+	ImageLongitude   float64            `json:"imageLongitude" bson:"imageLongitude" validate:"min=-180,max=180"`
+	DistanceTreshold float64            `json:"distanceTreshold" bson:"distanceTreshold" validate:"gt=0"`
+	EncounterID      string             `json:"encounterId" bson:"encounterId"`
+	DeletedAt        *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy        int                `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
 }
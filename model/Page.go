@@ -0,0 +1,27 @@
+package model
+
+// PageRequest selects one page of a list ordered by SortBy (a JSON field name)
+// and continuing after the item encoded in Cursor. A zero Limit returns everything.
+type PageRequest struct {
+	SortBy     string
+	Descending bool
+	Limit      int
+	Cursor     string
+}
+
+type BoundingBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+// EncounterFilter narrows GET /encounters. Empty fields do not filter.
+type EncounterFilter struct {
+	Status      string
+	Type        string
+	MinXp       *int
+	MaxXp       *int
+	Name        string
+	BoundingBox *BoundingBox
+}
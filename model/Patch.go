@@ -0,0 +1,24 @@
+package model
+
+// PatchedFields names the fields, by their JSON names, that a merge patch sets
+// and the ones it removes.
+type PatchedFields struct {
+	Set   []string
+	Unset []string
+}
+
+func (fields PatchedFields) IsEmpty() bool {
+	return len(fields.Set) == 0 && len(fields.Unset) == 0
+}
+
+// Touches reports whether the patch sets or removes any of the given fields.
+func (fields PatchedFields) Touches(names ...string) bool {
+	for _, name := range names {
+		for _, field := range append(fields.Set, fields.Unset...) {
+			if field == name {
+				return true
+			}
+		}
+	}
+	return false
+}
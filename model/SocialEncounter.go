@@ -1,11 +1,25 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type SocialEncounter struct {
 	ID                            primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	EncounterID                   string             `json:"encounterId"`
-	TouristsRequiredForCompletion int                `json:"touristsRequiredForCompletion"`
-	DistanceTreshold              float64            `json:"distanceTreshold"`
-	TouristIDs                    []int              `json:"touristIDs"`
+	EncounterID                   string             `json:"encounterId" bson:"encounterId"`
+	TouristsRequiredForCompletion int                `json:"touristsRequiredForCompletion" bson:"touristsRequiredForCompletion" validate:"min=1"`
+	DistanceTreshold              float64            `json:"distanceTreshold" bson:"distanceTreshold" validate:"gt=0"`
+	TouristIDs                    []int              `json:"touristIDs" bson:"touristIDs"`
+	DeletedAt                     *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy                     int                `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
+}
+
+// SocialCheckIn is the outcome of a tourist checking in to a social encounter.
+// When enough tourists are present the encounter is completed for all of them.
+type SocialCheckIn struct {
+	SocialEncounter     *SocialEncounter `json:"socialEncounter"`
+	Completed           bool             `json:"completed"`
+	CompletedTouristIDs []int            `json:"completedTouristIDs,omitempty"`
 }
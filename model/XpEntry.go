@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// XpEntry is one award of experience points in the XP ledger.
+// Each execution, and each encounter per tourist, can be awarded at most once.
+type XpEntry struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      int                `json:"userId" bson:"userId"`
+	EncounterID primitive.ObjectID `json:"encounterId" bson:"encounterId"`
+	ExecutionID primitive.ObjectID `json:"executionId" bson:"executionId"`
+	XpPoints    int                `json:"xpPoints" bson:"xpPoints"`
+	AwardedAt   time.Time          `json:"awardedAt" bson:"awardedAt"`
+}
+
+type TouristXp struct {
+	UserID  int        `json:"userId"`
+	TotalXp int        `json:"totalXp"`
+	Entries []*XpEntry `json:"entries"`
+}
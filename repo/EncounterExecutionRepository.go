@@ -1,53 +1,25 @@
 package repo
 
 import (
+	"context"
 	"database-example/model"
+	"errors"
 
-	"gorm.io/gorm"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type EncounterExecutionRepository struct {
-	DatabaseConnection *gorm.DB
-}
-
-func (repo *EncounterExecutionRepository) FindByUserId(userID int) (model.EncounterExecution, error) {
-	encounter := model.EncounterExecution{}
-	dbResult := repo.DatabaseConnection.Where("user_id = ?", userID).Find(&encounter)
-	if dbResult.Error != nil {
-		return encounter, dbResult.Error
-	}
-	return encounter, nil
-}
-
-func (repo *EncounterExecutionRepository) Update(encounter *model.EncounterExecution) error {
-	dbResult := repo.DatabaseConnection.Save(encounter)
-	if dbResult.Error != nil {
-		return dbResult.Error
-	}
-	return nil
-}
-
-func (repo *EncounterExecutionRepository) Create(encounter *model.EncounterExecution) error {
-	dbResult := repo.DatabaseConnection.Create(encounter)
-	if dbResult.Error != nil {
-		return dbResult.Error
-	}
-	return nil
-}
+var ErrExecutionNotFound = errors.New("encounter execution not found")
 
-func (repo *EncounterExecutionRepository) Delete(encounterExecId int) error {
-	dbResult := repo.DatabaseConnection.Exec("DELETE FROM encounter_executions WHERE id = ?", encounterExecId)
-	if dbResult.Error != nil {
-		return dbResult.Error
-	}
-	return nil
-}
+// ErrExecutionExists is returned by Create when the user already has an execution
+// of the encounter.
+var ErrExecutionExists = errors.New("encounter execution already exists")
 
-func (repo *EncounterExecutionRepository) GetAll() ([]*model.EncounterExecution, error) {
-	var encounters []*model.EncounterExecution
-	dbResult := repo.DatabaseConnection.Find(&encounters)
-	if dbResult.Error != nil {
-		return nil, dbResult.Error
-	}
-	return encounters, nil
+type EncounterExecutionRepository interface {
+	// FindByUserId returns the most recently started execution of the user.
+	FindByUserId(ctx context.Context, userID int) (model.EncounterExecution, error)
+	FindByUserAndEncounter(ctx context.Context, userID int, encounterID primitive.ObjectID) (model.EncounterExecution, error)
+	Update(ctx context.Context, execution *model.EncounterExecution) error
+	Create(ctx context.Context, execution *model.EncounterExecution) error
+	Delete(ctx context.Context, executionID string) error
+	GetAll(ctx context.Context) ([]*model.EncounterExecution, error)
 }
@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEncounterExecutionRepository_Find(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		first, second := primitive.NewObjectID(), primitive.NewObjectID()
+		older := &model.EncounterExecution{UserID: 5, EncounterID: first}
+		newer := &model.EncounterExecution{UserID: 5, EncounterID: second}
+		for _, execution := range []*model.EncounterExecution{older, newer} {
+			if err := r.executions.Create(ctx, execution); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if execution.ID.IsZero() {
+				t.Fatal("created execution has no id")
+			}
+		}
+
+		latest, err := r.executions.FindByUserId(ctx, 5)
+		if err != nil {
+			t.Fatalf("FindByUserId: %v", err)
+		}
+		if latest.ID != newer.ID {
+			t.Errorf("FindByUserId = %s, want the latest execution %s", latest.ID.Hex(), newer.ID.Hex())
+		}
+
+		found, err := r.executions.FindByUserAndEncounter(ctx, 5, first)
+		if err != nil {
+			t.Fatalf("FindByUserAndEncounter: %v", err)
+		}
+		if found.ID != older.ID {
+			t.Errorf("FindByUserAndEncounter = %s, want %s", found.ID.Hex(), older.ID.Hex())
+		}
+
+		if err := r.executions.Create(ctx, &model.EncounterExecution{UserID: 5, EncounterID: first}); !errors.Is(err, ErrExecutionExists) {
+			t.Errorf("second Create for the same user and encounter error = %v, want ErrExecutionExists", err)
+		}
+
+		if _, err := r.executions.FindByUserId(ctx, 6); !errors.Is(err, ErrExecutionNotFound) {
+			t.Errorf("FindByUserId of user without executions error = %v, want ErrExecutionNotFound", err)
+		}
+		if _, err := r.executions.FindByUserAndEncounter(ctx, 6, first); !errors.Is(err, ErrExecutionNotFound) {
+			t.Errorf("FindByUserAndEncounter error = %v, want ErrExecutionNotFound", err)
+		}
+	})
+}
+
+func TestEncounterExecutionRepository_UpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		execution := &model.EncounterExecution{UserID: 5, EncounterID: primitive.NewObjectID()}
+		if err := r.executions.Create(ctx, execution); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		execution.IsCompleted = true
+		if err := r.executions.Update(ctx, execution); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		updated, err := r.executions.FindByUserId(ctx, 5)
+		if err != nil {
+			t.Fatalf("FindByUserId: %v", err)
+		}
+		if !updated.IsCompleted {
+			t.Error("Update did not complete the execution")
+		}
+
+		missing := &model.EncounterExecution{ID: primitive.NewObjectID(), UserID: 5}
+		if err := r.executions.Update(ctx, missing); !errors.Is(err, ErrExecutionNotFound) {
+			t.Errorf("Update of missing execution error = %v, want ErrExecutionNotFound", err)
+		}
+
+		if err := r.executions.Delete(ctx, execution.ID.Hex()); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		for _, id := range []string{execution.ID.Hex(), "not-an-id"} {
+			if err := r.executions.Delete(ctx, id); !errors.Is(err, ErrExecutionNotFound) {
+				t.Errorf("Delete(%q) error = %v, want ErrExecutionNotFound", id, err)
+			}
+		}
+
+		executions, err := r.executions.GetAll(ctx)
+		if err != nil || len(executions) != 0 {
+			t.Errorf("GetAll = %v, %v, want none", executions, err)
+		}
+	})
+}
@@ -4,301 +4,77 @@ import (
 	"context"
 	"database-example/model"
 	"errors"
+	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
 )
 
-type EncounterRepository struct {
-	//DatabaseConnection *gorm.DB //za konekciju sa bazom podataka
-	DatabaseConnection *mongo.Client
+// DefaultDatabaseName is the Mongo database used when a repository does not name one.
+const DefaultDatabaseName = "SOAencounters"
+
+var ErrEncounterNotFound = errors.New("encounter not found")
+
+// ErrStatusChanged is returned by a write that expected the encounter in a status
+// it has left in the meantime.
+var ErrStatusChanged = errors.New("encounter status changed")
+
+var tracer = otel.Tracer("database-example/repo")
+
+// EncounterRepository stores encounters together with their social and hidden location parts.
+// Encounters moved to the trash are ignored by every method except the trash ones.
+type EncounterRepository interface {
+	CreateEncounter(ctx context.Context, encounter *model.Encounter) (*model.Encounter, error)
+	CreateEncounterWithDetails(ctx context.Context, details *model.EncounterDetails) (*model.EncounterDetails, error)
+	CreateSocialEncounter(ctx context.Context, encounter *model.SocialEncounter) error
+	CreateHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter) error
+
+	GetAllEncounters(ctx context.Context, viewerID int, filter model.EncounterFilter, page model.PageRequest) ([]*model.Encounter, string, error)
+	GetPendingEncounters(ctx context.Context) ([]*model.Encounter, error)
+	GetEncountersByAuthor(ctx context.Context, authorID int) ([]*model.Encounter, error)
+	GetNearbyEncounters(ctx context.Context, latitude, longitude, radius float64, viewerID int) ([]*model.NearbyEncounter, error)
+	// GetAllHiddenLocationEncounters and GetAllSocialEncounters leave out the parts of
+	// encounters the viewer may not see, like GetAllEncounters does.
+	GetAllHiddenLocationEncounters(ctx context.Context, viewerID int, page model.PageRequest) ([]*model.HiddenLocationEncounter, string, error)
+	GetAllSocialEncounters(ctx context.Context, viewerID int, page model.PageRequest) ([]*model.SocialEncounter, string, error)
+
+	GetEncounterById(ctx context.Context, encounterID string) (*model.Encounter, error)
+	GetHiddenLocationEncounterById(ctx context.Context, hiddenLocationEncounterID string) (*model.HiddenLocationEncounter, error)
+	GetSocialEncounterById(ctx context.Context, socialEncounterID string) (*model.SocialEncounter, error)
+	GetSocialEncounterByEncounterId(ctx context.Context, encounterID string) (*model.SocialEncounter, error)
+	GetHiddenLocationEncounterByEncounterId(ctx context.Context, encounterID string) (*model.HiddenLocationEncounter, error)
+
+	ReviewEncounter(ctx context.Context, encounterID primitive.ObjectID, approval *model.EncounterApproval, status string) (bool, error)
+	UpdateStatus(ctx context.Context, encounterID primitive.ObjectID, expectedStatus string, status string) (bool, error)
+	CheckInTourist(ctx context.Context, socialEncounterID primitive.ObjectID, touristID int) (*model.SocialEncounter, error)
+	CheckOutTourists(ctx context.Context, socialEncounterID primitive.ObjectID, touristIDs ...int) (*model.SocialEncounter, error)
+	// Update, UpdateHiddenLocationEncounter and UpdateSocialEncounter return the document
+	// as stored after the update, or ErrEncounterNotFound when no document has the id.
+	// Update writes the encounter only if it is still in expectedStatus and returns
+	// ErrStatusChanged otherwise.
+	Update(ctx context.Context, encounter *model.Encounter, expectedStatus string) (*model.Encounter, error)
+	UpdateHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter) (*model.HiddenLocationEncounter, error)
+	UpdateSocialEncounter(ctx context.Context, encounter *model.SocialEncounter) (*model.SocialEncounter, error)
+	// PatchEncounter, PatchHiddenLocationEncounter and PatchSocialEncounter write only the
+	// patched fields of the merged document and return the document as stored afterwards.
+	// A non-empty expectedStatus makes PatchEncounter behave like Update.
+	PatchEncounter(ctx context.Context, encounter *model.Encounter, fields model.PatchedFields, expectedStatus string) (*model.Encounter, error)
+	PatchHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter, fields model.PatchedFields) (*model.HiddenLocationEncounter, error)
+	PatchSocialEncounter(ctx context.Context, encounter *model.SocialEncounter, fields model.PatchedFields) (*model.SocialEncounter, error)
+
+	SoftDeleteEncounter(ctx context.Context, baseEncounterID string, deletedBy int) error
+	RestoreEncounter(ctx context.Context, baseEncounterID string) (*model.Encounter, error)
+	GetDeletedEncounters(ctx context.Context) ([]*model.Encounter, error)
+	GetEncounterIdsDeletedBefore(ctx context.Context, before time.Time) ([]string, error)
+	// PurgeEncounter removes the encounter only if it is still in the trash since before
+	// the given time, so one restored in the meantime is kept.
+	PurgeEncounter(ctx context.Context, baseEncounterID string, deletedBefore time.Time) (*model.EncounterDeletion, error)
+}
+
+func mongoDatabase(client *mongo.Client, name string) *mongo.Database {
+	if name == "" {
+		name = DefaultDatabaseName
+	}
+	return client.Database(name)
 }
-
-func (repo *EncounterRepository) CreateEncounter(encounter *model.Encounter) (*model.Encounter, error) {
-	collection := repo.DatabaseConnection.Database("SOAencounters").Collection("encounters")
-
-	ctx := context.TODO()
-	encounter.ID = primitive.NewObjectID()
-	_, err := collection.InsertOne(ctx, encounter)
-	if err != nil {
-		return nil, err
-	}
-
-	filter := bson.D{{Key: "_id", Value: encounter.ID}}
-
-	var createdEncounter model.Encounter
-	err = collection.FindOne(ctx, filter).Decode(&createdEncounter)
-	if err != nil {
-		return nil, err
-	}
-
-	return &createdEncounter, nil
-}
-
-func (repo *EncounterRepository) CreateSocialEncounter(encounter *model.SocialEncounter) error {
-	collection := repo.DatabaseConnection.Database("SOAencounters").Collection("socialEncounters")
-
-	ctx := context.TODO()
-
-	_, err := collection.InsertOne(ctx, encounter)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func (repo *EncounterRepository) CreateHiddenLocationEncounter(encounter *model.HiddenLocationEncounter) error {
-	collection := repo.DatabaseConnection.Database("SOAencounters").Collection("hiddenLocationEncounters")
-
-	ctx := context.TODO()
-
-	_, err := collection.InsertOne(ctx, encounter)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func (r *EncounterRepository) GetAllEncounters() ([]*model.Encounter, error) {
-
-	filter := bson.D{}
-
-	cursor, err := r.DatabaseConnection.Database("SOAencounters").Collection("encounters").Find(context.Background(), filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(context.Background())
-
-	var encounters []*model.Encounter
-	for cursor.Next(context.Background()) {
-		var encounter model.Encounter
-		if err := cursor.Decode(&encounter); err != nil {
-			return nil, err
-		}
-
-		encounters = append(encounters, &encounter)
-	}
-
-	return encounters, nil
-}
-
-func (r *EncounterRepository) GetAllHiddenLocationEncounters() ([]*model.HiddenLocationEncounter, error) {
-	filter := bson.D{}
-
-	cursor, err := r.DatabaseConnection.Database("SOAencounters").Collection("hiddenLocationEncounters").Find(context.Background(), filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(context.Background())
-
-	var encounters []*model.HiddenLocationEncounter
-	for cursor.Next(context.Background()) {
-		var encounter model.HiddenLocationEncounter
-		if err := cursor.Decode(&encounter); err != nil {
-			return nil, err
-		}
-
-		encounters = append(encounters, &encounter)
-	}
-
-	return encounters, nil
-}
-
-func (r *EncounterRepository) GetAllSocialEncounters() ([]*model.SocialEncounter, error) {
-	filter := bson.D{}
-
-	cursor, err := r.DatabaseConnection.Database("SOAencounters").Collection("socialEncounters").Find(context.Background(), filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(context.Background())
-
-	var encounters []*model.SocialEncounter
-	for cursor.Next(context.Background()) {
-		var encounter model.SocialEncounter
-		if err := cursor.Decode(&encounter); err != nil {
-			return nil, err
-		}
-
-		encounters = append(encounters, &encounter)
-	}
-
-	return encounters, nil
-}
-
-func (repo *EncounterRepository) Update(encounter *model.Encounter) error {
-	filter := bson.M{"_id": encounter.ID}
-
-	update := bson.M{
-		"$set": bson.M{
-			"name":             encounter.Name,
-			"description":      encounter.Description,
-			"xppoints":         encounter.XpPoints,
-			"status":           encounter.Status,
-			"type":             encounter.Type,
-			"longitude":        encounter.Longitude,
-			"latitude":         encounter.Latitude,
-			"shouldbeapproved": encounter.ShouldBeApproved,
-		},
-	}
-
-	_, err := repo.DatabaseConnection.Database("SOAencounters").Collection("encounters").UpdateOne(context.TODO(), filter, update)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (repo *EncounterRepository) UpdateHiddenLocationEncounter(encounter *model.HiddenLocationEncounter) error {
-
-	filter := bson.M{"_id": encounter.ID}
-
-	update := bson.M{
-		"$set": bson.M{
-			"imageurl":         encounter.ImageURL,
-			"imagelatitude":    encounter.ImageLatitude,
-			"imagelongitude":   encounter.ImageLongitude,
-			"distancetreshold": encounter.DistanceTreshold,
-			"encounterid":      encounter.EncounterID,
-		},
-	}
-
-	_, err := repo.DatabaseConnection.Database("SOAencounters").Collection("hiddenLocationEncounters").UpdateOne(context.TODO(), filter, update)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (repo *EncounterRepository) UpdateSocialEncounter(encounter *model.SocialEncounter) error {
-
-	filter := bson.M{"_id": encounter.ID}
-
-	update := bson.M{
-		"$set": bson.M{
-			"encounterid":                   encounter.EncounterID,
-			"touristsrequiredforcompletion": encounter.TouristsRequiredForCompletion,
-			"distancetreshold":              encounter.DistanceTreshold,
-			"touristids":                    encounter.TouristIDs,
-		},
-	}
-
-	_, err := repo.DatabaseConnection.Database("SOAencounters").Collection("socialEncounters").UpdateOne(context.TODO(), filter, update)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (r *EncounterRepository) DeleteEncounter(baseEncounterID string) error {
-
-	objectID, err := primitive.ObjectIDFromHex(baseEncounterID)
-	if err != nil {
-		return err
-	}
-
-	filter := bson.M{"_id": objectID}
-
-	result, err := r.DatabaseConnection.Database("SOAencounters").Collection("encounters").DeleteOne(context.TODO(), filter)
-	if err != nil {
-		return err
-	}
-
-	if result.DeletedCount == 0 {
-		return errors.New("encounter not found")
-	}
-
-	hiddenLocationFilter := bson.M{"encounterid": baseEncounterID}
-	_, err = r.DatabaseConnection.Database("SOAencounters").Collection("hiddenLocationEncounters").DeleteMany(context.TODO(), hiddenLocationFilter)
-	if err != nil {
-		return err
-	}
-
-	socialFilter := bson.M{"encounterid": baseEncounterID}
-	_, err = r.DatabaseConnection.Database("SOAencounters").Collection("socialEncounters").DeleteMany(context.TODO(), socialFilter)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-/* ono od pre jer vise ne treba jer sam uradila brisanje povezanih social i location na laksi nacin odmah u brisanju
-func (r *EncounterRepository) GetSocialEncounterId(baseEncounterID int) (int, error) {
-	var socialEncounterID int
-
-	result := r.DatabaseConnection.Model(&model.SocialEncounter{}).Select("id").Where("encounter_id = ?", baseEncounterID).First(&socialEncounterID)
-	if result.Error != nil {
-    if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return -1, nil
-		}
-		return 0, result.Error
-	}
-	return socialEncounterID, nil
-}
-
-func (r *EncounterRepository) GetHiddenLocationEncounterId(baseEncounterID int) (int, error) {
-	var hiddenLocationEncounterID int
-
-	result := r.DatabaseConnection.Model(&model.HiddenLocationEncounter{}).Select("id").Where("encounter_id = ?", baseEncounterID).First(&hiddenLocationEncounterID)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return -1, nil
-		}
-		return 0, result.Error
-	}
-
-	// Ako nema greške, vraćamo ID društvenog susreta
-	return hiddenLocationEncounterID, nil
-}
-
-func (r *EncounterRepository) DeleteSocialEncounter(socialEncounterID int) error {
-	result := r.DatabaseConnection.Exec("DELETE FROM social_encounters WHERE id = ?", socialEncounterID)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
-}
-
-func (r *EncounterRepository) DeleteHiddenLocationEncounter(hiddenLocationEncounterID int) error {
-
-	result := r.DatabaseConnection.Exec("DELETE FROM hidden_location_encounters WHERE id = ?", hiddenLocationEncounterID)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
-}
-
-
-
-func (r *EncounterRepository) GetHiddenLocationEncounterByEncounterId(baseEncounterID int) (*model.HiddenLocationEncounter, error) {
-	var hiddenLocationEncounter model.HiddenLocationEncounter
-
-	result := r.DatabaseConnection.Model(&model.HiddenLocationEncounter{}).Where("encounter_id = ?", baseEncounterID).First(&hiddenLocationEncounter)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
-		return nil, result.Error
-	}
-	return &hiddenLocationEncounter, nil
-}
-
-func (r *EncounterRepository) GetEncounterById(encounterId int) (*model.Encounter, error) {
-	var encounter model.Encounter
-
-	result := r.DatabaseConnection.Model(&model.Encounter{}).Where("id = ?", encounterId).First(&encounter)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
-		return nil, result.Error
-	}
-
-	return &encounter, nil
-}
-*/
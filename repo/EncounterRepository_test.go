@@ -0,0 +1,679 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func createEncounter(t *testing.T, r repositories, encounter model.Encounter) *model.Encounter {
+	t.Helper()
+	ctx := context.Background()
+	if encounter.Status == "" {
+		encounter.Status = model.Active.String()
+	}
+	if encounter.Type == "" {
+		encounter.Type = model.Misc.String()
+	}
+	created, err := r.encounters.CreateEncounter(ctx, &encounter)
+	if err != nil {
+		t.Fatalf("CreateEncounter: %v", err)
+	}
+	return created
+}
+
+func encounterNames(encounters []*model.Encounter) []string {
+	names := []string{}
+	for _, encounter := range encounters {
+		names = append(names, encounter.Name)
+	}
+	return names
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEncounterRepository_CreateAndGetById(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		created := createEncounter(t, r, model.Encounter{Name: "Fountain", XpPoints: 20, Latitude: 45.25, Longitude: 19.84})
+		if created.ID.IsZero() {
+			t.Fatal("created encounter has no id")
+		}
+
+		found, err := r.encounters.GetEncounterById(ctx, created.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetEncounterById: %v", err)
+		}
+		if found.Name != "Fountain" || found.XpPoints != 20 || found.Latitude != 45.25 {
+			t.Errorf("got %+v", found)
+		}
+
+		for _, id := range []string{primitive.NewObjectID().Hex(), "not-an-id"} {
+			if _, err := r.encounters.GetEncounterById(ctx, id); !errors.Is(err, ErrEncounterNotFound) {
+				t.Errorf("GetEncounterById(%q) error = %v, want ErrEncounterNotFound", id, err)
+			}
+		}
+	})
+}
+
+func TestEncounterRepository_CreateEncounterWithDetails(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		details := &model.EncounterDetails{
+			Encounter:       model.Encounter{Name: "Square", Status: model.Active.String(), Type: model.Social.String()},
+			SocialEncounter: &model.SocialEncounter{TouristsRequiredForCompletion: 3, DistanceTreshold: 50},
+		}
+		created, err := r.encounters.CreateEncounterWithDetails(ctx, details)
+		if err != nil {
+			t.Fatalf("CreateEncounterWithDetails: %v", err)
+		}
+
+		social, err := r.encounters.GetSocialEncounterByEncounterId(ctx, created.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetSocialEncounterByEncounterId: %v", err)
+		}
+		if social.ID != created.SocialEncounter.ID || social.TouristsRequiredForCompletion != 3 {
+			t.Errorf("got %+v, want %+v", social, created.SocialEncounter)
+		}
+		if len(social.TouristIDs) != 0 {
+			t.Errorf("TouristIDs = %v, want none", social.TouristIDs)
+		}
+
+		if _, err := r.encounters.GetHiddenLocationEncounterByEncounterId(ctx, created.ID.Hex()); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("GetHiddenLocationEncounterByEncounterId error = %v, want ErrEncounterNotFound", err)
+		}
+	})
+}
+
+func TestEncounterRepository_Visibility(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		createEncounter(t, r, model.Encounter{Name: "Public", AuthorID: 1})
+		proposal := createEncounter(t, r, model.Encounter{
+			Name:             "Proposal",
+			Status:           model.Draft.String(),
+			ShouldBeApproved: true,
+			AuthorID:         7,
+			Approval:         &model.EncounterApproval{Decision: model.ApprovalPending},
+		})
+
+		tests := []struct {
+			name     string
+			viewerID int
+			want     []string
+		}{
+			{"anonymous", 0, []string{"Public"}},
+			{"other tourist", 8, []string{"Public"}},
+			{"author", 7, []string{"Public", "Proposal"}},
+		}
+		for _, tt := range tests {
+			encounters, _, err := r.encounters.GetAllEncounters(ctx, tt.viewerID, model.EncounterFilter{}, model.PageRequest{})
+			if err != nil {
+				t.Fatalf("%s: GetAllEncounters: %v", tt.name, err)
+			}
+			if got := encounterNames(encounters); !equalNames(got, tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+		}
+
+		pending, err := r.encounters.GetPendingEncounters(ctx)
+		if err != nil {
+			t.Fatalf("GetPendingEncounters: %v", err)
+		}
+		if got := encounterNames(pending); !equalNames(got, []string{"Proposal"}) {
+			t.Errorf("pending = %v", got)
+		}
+
+		approval := &model.EncounterApproval{Decision: model.ApprovalApproved, ReviewerID: 1}
+		reviewed, err := r.encounters.ReviewEncounter(ctx, proposal.ID, approval, model.Active.String())
+		if err != nil || !reviewed {
+			t.Fatalf("ReviewEncounter = %v, %v, want true", reviewed, err)
+		}
+		reviewed, err = r.encounters.ReviewEncounter(ctx, proposal.ID, approval, model.Active.String())
+		if err != nil || reviewed {
+			t.Errorf("second ReviewEncounter = %v, %v, want false", reviewed, err)
+		}
+
+		encounters, _, err := r.encounters.GetAllEncounters(ctx, 0, model.EncounterFilter{}, model.PageRequest{})
+		if err != nil {
+			t.Fatalf("GetAllEncounters: %v", err)
+		}
+		if got := encounterNames(encounters); !equalNames(got, []string{"Public", "Proposal"}) {
+			t.Errorf("after approval got %v", got)
+		}
+	})
+}
+
+func TestEncounterRepository_UpdateStatus(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		current  model.EncounterStatus
+		expected model.EncounterStatus
+		status   model.EncounterStatus
+		want     bool
+	}{
+		{"expected status matches", model.Draft, model.Draft, model.Active, true},
+		{"status changed meanwhile", model.Archived, model.Draft, model.Active, false},
+		{"already in status", model.Active, model.Active, model.Active, false},
+	}
+
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		for _, tt := range tests {
+			encounter := createEncounter(t, r, model.Encounter{Name: tt.name, Status: tt.current.String()})
+
+			updated, err := r.encounters.UpdateStatus(ctx, encounter.ID, tt.expected.String(), tt.status.String())
+			if err != nil {
+				t.Fatalf("%s: UpdateStatus: %v", tt.name, err)
+			}
+			if updated != tt.want {
+				t.Errorf("%s: UpdateStatus = %v, want %v", tt.name, updated, tt.want)
+			}
+		}
+
+		updated, err := r.encounters.UpdateStatus(ctx, primitive.NewObjectID(), model.Draft.String(), model.Active.String())
+		if err != nil || updated {
+			t.Errorf("UpdateStatus of missing encounter = %v, %v, want false", updated, err)
+		}
+	})
+}
+
+func TestEncounterRepository_GetAllEncountersFilter(t *testing.T) {
+	ctx := context.Background()
+	intPointer := func(value int) *int { return &value }
+	tests := []struct {
+		name   string
+		filter model.EncounterFilter
+		want   []string
+	}{
+		{"no filter", model.EncounterFilter{}, []string{"Bridge", "Castle", "Market", "Harbor"}},
+		{"status ignores case", model.EncounterFilter{Status: "archived"}, []string{"Market"}},
+		{"type", model.EncounterFilter{Type: "Social"}, []string{"Castle"}},
+		{"xp range", model.EncounterFilter{MinXp: intPointer(20), MaxXp: intPointer(40)}, []string{"Castle", "Market"}},
+		{"name contains", model.EncounterFilter{Name: "AR"}, []string{"Market", "Harbor"}},
+		{"bounding box", model.EncounterFilter{BoundingBox: &model.BoundingBox{
+			MinLatitude: 44, MaxLatitude: 46, MinLongitude: 19, MaxLongitude: 21,
+		}}, []string{"Bridge", "Castle"}},
+		{"bounding box across the antimeridian", model.EncounterFilter{BoundingBox: &model.BoundingBox{
+			MinLatitude: -90, MaxLatitude: 90, MinLongitude: 170, MaxLongitude: -170,
+		}}, []string{"Harbor"}},
+	}
+
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		createEncounter(t, r, model.Encounter{Name: "Bridge", XpPoints: 10, Latitude: 45.25, Longitude: 19.86})
+		createEncounter(t, r, model.Encounter{Name: "Castle", XpPoints: 30, Type: model.Social.String(), Latitude: 44.82, Longitude: 20.45})
+		createEncounter(t, r, model.Encounter{Name: "Market", XpPoints: 20, Status: model.Archived.String(), Latitude: 48.2, Longitude: 16.37})
+		createEncounter(t, r, model.Encounter{Name: "Harbor", XpPoints: 50, Latitude: -18.14, Longitude: 178.44})
+
+		for _, tt := range tests {
+			encounters, next, err := r.encounters.GetAllEncounters(ctx, 0, tt.filter, model.PageRequest{})
+			if err != nil {
+				t.Fatalf("%s: GetAllEncounters: %v", tt.name, err)
+			}
+			if got := encounterNames(encounters); !equalNames(got, tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+			if next != "" {
+				t.Errorf("%s: next cursor = %q, want none", tt.name, next)
+			}
+		}
+	})
+}
+
+func TestEncounterRepository_PartVisibility(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		parts := map[string]string{}
+		for _, encounter := range []model.Encounter{
+			{Name: "Public", AuthorID: 1},
+			{Name: "Pending", ShouldBeApproved: true, AuthorID: 7, Approval: &model.EncounterApproval{Decision: model.ApprovalPending}},
+			{Name: "Rejected", ShouldBeApproved: true, AuthorID: 8, Approval: &model.EncounterApproval{Decision: model.ApprovalRejected}},
+		} {
+			encounter.Status = model.Draft.String()
+			encounter.Type = model.Social.String()
+			details, err := r.encounters.CreateEncounterWithDetails(ctx, &model.EncounterDetails{
+				Encounter:       encounter,
+				SocialEncounter: &model.SocialEncounter{TouristsRequiredForCompletion: 2, DistanceTreshold: 50},
+			})
+			if err != nil {
+				t.Fatalf("CreateEncounterWithDetails: %v", err)
+			}
+			parts[details.ID.Hex()] = encounter.Name
+		}
+
+		tests := []struct {
+			name     string
+			viewerID int
+			want     []string
+		}{
+			{"anonymous", 0, []string{"Public"}},
+			{"author of the pending proposal", 7, []string{"Public", "Pending"}},
+			{"author of the rejected proposal", 8, []string{"Public", "Rejected"}},
+		}
+		for _, tt := range tests {
+			socialEncounters, _, err := r.encounters.GetAllSocialEncounters(ctx, tt.viewerID, model.PageRequest{})
+			if err != nil {
+				t.Fatalf("%s: GetAllSocialEncounters: %v", tt.name, err)
+			}
+			var got []string
+			for _, socialEncounter := range socialEncounters {
+				got = append(got, parts[socialEncounter.EncounterID])
+			}
+			if !equalNames(got, tt.want) {
+				t.Errorf("%s: got parts of %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestEncounterRepository_GetAllEncountersPages(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		page model.PageRequest
+		want []string
+	}{
+		{"by id", model.PageRequest{Limit: 2}, []string{"A", "B", "C", "D", "E"}},
+		{"by xp descending", model.PageRequest{SortBy: "xpPoints", Descending: true, Limit: 2}, []string{"B", "D", "E", "A", "C"}},
+		{"by name", model.PageRequest{SortBy: "name", Limit: 3}, []string{"A", "B", "C", "D", "E"}},
+	}
+
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		for i, xp := range []int{10, 40, 5, 30, 10} {
+			createEncounter(t, r, model.Encounter{Name: string(rune('A' + i)), XpPoints: xp})
+		}
+
+		for _, tt := range tests {
+			var got []string
+			page := tt.page
+			for pages := 0; ; pages++ {
+				if pages > len(tt.want) {
+					t.Fatalf("%s: pagination does not end", tt.name)
+				}
+				encounters, next, err := r.encounters.GetAllEncounters(ctx, 0, model.EncounterFilter{}, page)
+				if err != nil {
+					t.Fatalf("%s: GetAllEncounters: %v", tt.name, err)
+				}
+				if len(encounters) > page.Limit {
+					t.Fatalf("%s: page has %d encounters, limit is %d", tt.name, len(encounters), page.Limit)
+				}
+				got = append(got, encounterNames(encounters)...)
+				if next == "" {
+					break
+				}
+				page.Cursor = next
+			}
+			if !equalNames(got, tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+		}
+
+		invalid := []model.PageRequest{
+			{SortBy: "description"},
+			{Limit: MaxPageSize + 1},
+			{Cursor: "not a cursor"},
+		}
+		for _, page := range invalid {
+			if _, _, err := r.encounters.GetAllEncounters(ctx, 0, model.EncounterFilter{}, page); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("GetAllEncounters(%+v) error = %v, want ErrInvalidQuery", page, err)
+			}
+		}
+	})
+}
+
+func TestEncounterRepository_GetAllEncountersWithoutLimit(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		const count = 60
+		for i := 0; i < count; i++ {
+			createEncounter(t, r, model.Encounter{Name: fmt.Sprintf("Encounter %d", i)})
+		}
+
+		encounters, next, err := r.encounters.GetAllEncounters(ctx, 0, model.EncounterFilter{}, model.PageRequest{})
+		if err != nil {
+			t.Fatalf("GetAllEncounters: %v", err)
+		}
+		if len(encounters) != count || next != "" {
+			t.Errorf("got %d encounters and next cursor %q, want all %d and no cursor", len(encounters), next, count)
+		}
+	})
+}
+
+func TestEncounterRepository_GetNearbyEncounters(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		// Oko 1.1km, 110m i 11km od tacke pretrage
+		createEncounter(t, r, model.Encounter{Name: "Kilometer", Latitude: 45.01, Longitude: 19})
+		createEncounter(t, r, model.Encounter{Name: "Close", Latitude: 45.001, Longitude: 19})
+		createEncounter(t, r, model.Encounter{Name: "Far", Latitude: 45.1, Longitude: 19})
+
+		encounters, err := r.encounters.GetNearbyEncounters(ctx, 45, 19, 2000, 0)
+		if err != nil {
+			t.Fatalf("GetNearbyEncounters: %v", err)
+		}
+		if len(encounters) != 2 || encounters[0].Name != "Close" || encounters[1].Name != "Kilometer" {
+			t.Fatalf("got %+v, want Close and Kilometer", encounters)
+		}
+		if encounters[0].Distance < 100 || encounters[0].Distance > 120 {
+			t.Errorf("distance to Close = %f, want about 111m", encounters[0].Distance)
+		}
+	})
+}
+
+func TestEncounterRepository_CheckInAndOut(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		social := &model.SocialEncounter{EncounterID: primitive.NewObjectID().Hex(), TouristsRequiredForCompletion: 2}
+		if err := r.encounters.CreateSocialEncounter(ctx, social); err != nil {
+			t.Fatalf("CreateSocialEncounter: %v", err)
+		}
+
+		for _, touristID := range []int{1, 2, 1} {
+			if _, err := r.encounters.CheckInTourist(ctx, social.ID, touristID); err != nil {
+				t.Fatalf("CheckInTourist(%d): %v", touristID, err)
+			}
+		}
+		checkedIn, err := r.encounters.GetSocialEncounterById(ctx, social.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetSocialEncounterById: %v", err)
+		}
+		if len(checkedIn.TouristIDs) != 2 {
+			t.Errorf("TouristIDs = %v, want each tourist once", checkedIn.TouristIDs)
+		}
+
+		checkedOut, err := r.encounters.CheckOutTourists(ctx, social.ID, 1, 2)
+		if err != nil {
+			t.Fatalf("CheckOutTourists: %v", err)
+		}
+		if len(checkedOut.TouristIDs) != 0 {
+			t.Errorf("TouristIDs = %v, want none", checkedOut.TouristIDs)
+		}
+
+		if _, err := r.encounters.CheckInTourist(ctx, primitive.NewObjectID(), 1); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("CheckInTourist of missing encounter error = %v, want ErrEncounterNotFound", err)
+		}
+	})
+}
+
+func TestEncounterRepository_Update(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		encounter := createEncounter(t, r, model.Encounter{Name: "Old", AuthorID: 3, Latitude: 45, Longitude: 19})
+
+		encounter.Name = "New"
+		encounter.Latitude = 46
+		encounter.AuthorID = 4
+		encounter.XpPoints = 30
+		updated, err := r.encounters.Update(ctx, encounter, model.Active.String())
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if updated.Name != "New" || updated.Latitude != 46 || updated.XpPoints != 30 {
+			t.Errorf("got %+v", updated)
+		}
+		if updated.AuthorID != 3 {
+			t.Errorf("AuthorID = %d, Update must not change it", updated.AuthorID)
+		}
+
+		stored, err := r.encounters.GetEncounterById(ctx, encounter.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetEncounterById: %v", err)
+		}
+		if stored.XpPoints != 30 {
+			t.Errorf("stored XpPoints = %d, want 30", stored.XpPoints)
+		}
+
+		if _, err := r.encounters.Update(ctx, &model.Encounter{ID: primitive.NewObjectID(), Name: "Missing"}, model.Active.String()); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("Update of a missing encounter error = %v, want ErrEncounterNotFound", err)
+		}
+
+		nearby, err := r.encounters.GetNearbyEncounters(ctx, 46, 19, 10, 0)
+		if err != nil {
+			t.Fatalf("GetNearbyEncounters: %v", err)
+		}
+		if len(nearby) != 1 {
+			t.Errorf("Update did not move the location, nearby = %+v", nearby)
+		}
+
+		// Neko drugi je u medjuvremenu arhivirao susret
+		if _, err := r.encounters.UpdateStatus(ctx, encounter.ID, model.Active.String(), model.Archived.String()); err != nil {
+			t.Fatalf("UpdateStatus: %v", err)
+		}
+		stale := *updated
+		stale.Name = "Stale"
+		if _, err := r.encounters.Update(ctx, &stale, model.Active.String()); !errors.Is(err, ErrStatusChanged) {
+			t.Errorf("Update of an encounter that left the status error = %v, want ErrStatusChanged", err)
+		}
+		if _, err := r.encounters.PatchEncounter(ctx, &stale, model.PatchedFields{Set: []string{"name"}}, model.Active.String()); !errors.Is(err, ErrStatusChanged) {
+			t.Errorf("PatchEncounter of an encounter that left the status error = %v, want ErrStatusChanged", err)
+		}
+	})
+}
+
+func TestEncounterRepository_PatchEncounter(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		encounter := createEncounter(t, r, model.Encounter{Name: "Old", Description: "Stone bridge", XpPoints: 10, Latitude: 45, Longitude: 19})
+
+		// Drugi urednik je u medjuvremenu promenio ime
+		renamed := *encounter
+		renamed.Name = "Renamed"
+		if _, err := r.encounters.Update(ctx, &renamed, encounter.Status); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		merged := *encounter
+		merged.XpPoints = 30
+		merged.Description = ""
+		merged.Latitude = 46
+		patched, err := r.encounters.PatchEncounter(ctx, &merged, model.PatchedFields{Set: []string{"latitude", "xpPoints"}, Unset: []string{"description"}}, "")
+		if err != nil {
+			t.Fatalf("PatchEncounter: %v", err)
+		}
+		if patched.Name != "Renamed" || patched.XpPoints != 30 || patched.Description != "" || patched.Latitude != 46 {
+			t.Errorf("got %+v, want only the patched fields changed", patched)
+		}
+
+		nearby, err := r.encounters.GetNearbyEncounters(ctx, 46, 19, 10, 0)
+		if err != nil {
+			t.Fatalf("GetNearbyEncounters: %v", err)
+		}
+		if len(nearby) != 1 {
+			t.Errorf("PatchEncounter did not move the location, nearby = %+v", nearby)
+		}
+
+		missing := model.Encounter{ID: primitive.NewObjectID()}
+		if _, err := r.encounters.PatchEncounter(ctx, &missing, model.PatchedFields{Set: []string{"name"}}, ""); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("PatchEncounter of a missing encounter error = %v, want ErrEncounterNotFound", err)
+		}
+	})
+}
+
+func TestEncounterRepository_UpdateSocialEncounter(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		encounter := createEncounter(t, r, model.Encounter{Name: "Square", Type: model.Social.String()})
+		social := &model.SocialEncounter{EncounterID: encounter.ID.Hex(), TouristsRequiredForCompletion: 3, DistanceTreshold: 20}
+		if err := r.encounters.CreateSocialEncounter(ctx, social); err != nil {
+			t.Fatalf("CreateSocialEncounter: %v", err)
+		}
+		if _, err := r.encounters.CheckInTourist(ctx, social.ID, 5); err != nil {
+			t.Fatalf("CheckInTourist: %v", err)
+		}
+
+		updated, err := r.encounters.UpdateSocialEncounter(ctx, &model.SocialEncounter{
+			ID:                            social.ID,
+			EncounterID:                   encounter.ID.Hex(),
+			TouristsRequiredForCompletion: 2,
+			DistanceTreshold:              40,
+		})
+		if err != nil {
+			t.Fatalf("UpdateSocialEncounter: %v", err)
+		}
+		if updated.TouristsRequiredForCompletion != 2 || updated.DistanceTreshold != 40 {
+			t.Errorf("got %+v", updated)
+		}
+		if len(updated.TouristIDs) != 1 || updated.TouristIDs[0] != 5 {
+			t.Errorf("TouristIDs = %v, Update must keep the checked in tourists", updated.TouristIDs)
+		}
+
+		if _, err := r.encounters.UpdateSocialEncounter(ctx, &model.SocialEncounter{ID: primitive.NewObjectID()}); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("UpdateSocialEncounter of a missing encounter error = %v, want ErrEncounterNotFound", err)
+		}
+	})
+}
+
+func TestEncounterRepository_Trash(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		details, err := r.encounters.CreateEncounterWithDetails(ctx, &model.EncounterDetails{
+			Encounter:               model.Encounter{Name: "Ruins", Status: model.Active.String(), Type: model.Location.String()},
+			HiddenLocationEncounter: &model.HiddenLocationEncounter{ImageURL: "ruins.jpg", DistanceTreshold: 10},
+		})
+		if err != nil {
+			t.Fatalf("CreateEncounterWithDetails: %v", err)
+		}
+		id := details.ID.Hex()
+
+		if err := r.encounters.SoftDeleteEncounter(ctx, id, 9); err != nil {
+			t.Fatalf("SoftDeleteEncounter: %v", err)
+		}
+		if err := r.encounters.SoftDeleteEncounter(ctx, id, 9); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("second SoftDeleteEncounter error = %v, want ErrEncounterNotFound", err)
+		}
+		if _, err := r.encounters.GetEncounterById(ctx, id); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("GetEncounterById of deleted encounter error = %v, want ErrEncounterNotFound", err)
+		}
+		if _, err := r.encounters.GetHiddenLocationEncounterById(ctx, details.HiddenLocationEncounter.ID.Hex()); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("GetHiddenLocationEncounterById of deleted part error = %v, want ErrEncounterNotFound", err)
+		}
+
+		deleted, err := r.encounters.GetDeletedEncounters(ctx)
+		if err != nil {
+			t.Fatalf("GetDeletedEncounters: %v", err)
+		}
+		if len(deleted) != 1 || deleted[0].DeletedBy != 9 || deleted[0].DeletedAt == nil {
+			t.Errorf("deleted = %+v", deleted)
+		}
+
+		restored, err := r.encounters.RestoreEncounter(ctx, id)
+		if err != nil {
+			t.Fatalf("RestoreEncounter: %v", err)
+		}
+		if restored.DeletedAt != nil {
+			t.Errorf("restored encounter still has DeletedAt")
+		}
+		if _, err := r.encounters.GetHiddenLocationEncounterById(ctx, details.HiddenLocationEncounter.ID.Hex()); err != nil {
+			t.Errorf("GetHiddenLocationEncounterById after restore: %v", err)
+		}
+		if _, err := r.encounters.RestoreEncounter(ctx, id); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("RestoreEncounter of encounter not in trash error = %v, want ErrEncounterNotFound", err)
+		}
+
+		if err := r.encounters.SoftDeleteEncounter(ctx, id, 9); err != nil {
+			t.Fatalf("SoftDeleteEncounter: %v", err)
+		}
+		ids, err := r.encounters.GetEncounterIdsDeletedBefore(ctx, time.Now().Add(-time.Hour))
+		if err != nil || len(ids) != 0 {
+			t.Errorf("GetEncounterIdsDeletedBefore an hour ago = %v, %v, want none", ids, err)
+		}
+		ids, err = r.encounters.GetEncounterIdsDeletedBefore(ctx, time.Now().Add(time.Hour))
+		if err != nil || len(ids) != 1 || ids[0] != id {
+			t.Errorf("GetEncounterIdsDeletedBefore = %v, %v, want [%s]", ids, err, id)
+		}
+	})
+}
+
+func TestEncounterRepository_PurgeKeepsRestoredEncounter(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		encounter := createEncounter(t, r, model.Encounter{Name: "Bridge"})
+		if err := r.encounters.SoftDeleteEncounter(ctx, encounter.ID.Hex(), 1); err != nil {
+			t.Fatalf("SoftDeleteEncounter: %v", err)
+		}
+		cutoff := time.Now().UTC().Add(time.Minute)
+		ids, err := r.encounters.GetEncounterIdsDeletedBefore(ctx, cutoff)
+		if err != nil || len(ids) != 1 {
+			t.Fatalf("GetEncounterIdsDeletedBefore = %v, %v", ids, err)
+		}
+
+		// Autor vraca susret izmedju listanja i brisanja
+		if _, err := r.encounters.RestoreEncounter(ctx, ids[0]); err != nil {
+			t.Fatalf("RestoreEncounter: %v", err)
+		}
+		if _, err := r.encounters.PurgeEncounter(ctx, ids[0], cutoff); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("PurgeEncounter of a restored encounter error = %v, want ErrEncounterNotFound", err)
+		}
+		if _, err := r.encounters.GetEncounterById(ctx, ids[0]); err != nil {
+			t.Errorf("restored encounter is gone: %v", err)
+		}
+	})
+}
+
+func TestEncounterRepository_PurgeEncounter(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		details, err := r.encounters.CreateEncounterWithDetails(ctx, &model.EncounterDetails{
+			Encounter:       model.Encounter{Name: "Square", Status: model.Active.String(), Type: model.Social.String()},
+			SocialEncounter: &model.SocialEncounter{TouristsRequiredForCompletion: 2},
+		})
+		if err != nil {
+			t.Fatalf("CreateEncounterWithDetails: %v", err)
+		}
+		for _, touristID := range []int{1, 2} {
+			if err := r.executions.Create(ctx, &model.EncounterExecution{UserID: touristID, EncounterID: details.ID}); err != nil {
+				t.Fatalf("Create execution: %v", err)
+			}
+		}
+		other := createEncounter(t, r, model.Encounter{Name: "Other"})
+		if err := r.executions.Create(ctx, &model.EncounterExecution{UserID: 1, EncounterID: other.ID}); err != nil {
+			t.Fatalf("Create execution: %v", err)
+		}
+
+		if err := r.encounters.SoftDeleteEncounter(ctx, details.ID.Hex(), 1); err != nil {
+			t.Fatalf("SoftDeleteEncounter: %v", err)
+		}
+		cutoff := time.Now().UTC().Add(time.Minute)
+		if err := r.encounters.SoftDeleteEncounter(ctx, other.ID.Hex(), 1); err != nil {
+			t.Fatalf("SoftDeleteEncounter: %v", err)
+		}
+		if _, err := r.encounters.PurgeEncounter(ctx, other.ID.Hex(), other.ID.Timestamp().Add(-time.Hour)); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("PurgeEncounter of an encounter deleted after the cutoff error = %v, want ErrEncounterNotFound", err)
+		}
+
+		deletion, err := r.encounters.PurgeEncounter(ctx, details.ID.Hex(), cutoff)
+		if err != nil {
+			t.Fatalf("PurgeEncounter: %v", err)
+		}
+		want := model.EncounterDeletion{Encounters: 1, SocialEncounters: 1, Executions: 2}
+		if *deletion != want {
+			t.Errorf("deletion = %+v, want %+v", *deletion, want)
+		}
+
+		executions, err := r.executions.GetAll(ctx)
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		if len(executions) != 1 || executions[0].EncounterID != other.ID {
+			t.Errorf("executions left = %+v", executions)
+		}
+		if _, err := r.encounters.PurgeEncounter(ctx, details.ID.Hex(), cutoff); !errors.Is(err, ErrEncounterNotFound) {
+			t.Errorf("second PurgeEncounter error = %v, want ErrEncounterNotFound", err)
+		}
+	})
+}
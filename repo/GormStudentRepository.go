@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"errors"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+var _ StudentRepository = (*GormStudentRepository)(nil)
+
+type GormStudentRepository struct {
+	DatabaseConnection *gorm.DB
+}
+
+func (repo *GormStudentRepository) FindById(ctx context.Context, id string) (model.Student, error) {
+	ctx, span := tracer.Start(ctx, "GormStudentRepository.FindById")
+	defer span.End()
+
+	student := model.Student{}
+	dbResult := repo.DatabaseConnection.WithContext(ctx).First(&student, "id = ?", id)
+	if errors.Is(dbResult.Error, gorm.ErrRecordNotFound) {
+		return student, ErrStudentNotFound
+	}
+	if dbResult.Error != nil {
+		return student, dbResult.Error
+	}
+	return student, nil
+}
+
+func (repo *GormStudentRepository) CreateStudent(ctx context.Context, student *model.Student) error {
+	ctx, span := tracer.Start(ctx, "GormStudentRepository.CreateStudent")
+	defer span.End()
+
+	dbResult := repo.DatabaseConnection.WithContext(ctx).Create(student)
+	if dbResult.Error != nil {
+		return dbResult.Error
+	}
+	slog.DebugContext(ctx, "Created student", "rows_affected", dbResult.RowsAffected)
+	return nil
+}
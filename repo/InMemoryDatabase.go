@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"database-example/model"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryDatabase keeps every collection in memory behind one lock, so an operation
+// spanning several collections, such as creating an encounter with its part or moving
+// it to the trash, is atomic like the transaction the Mongo repository runs it in.
+// Repositories store and return copies, never the documents themselves.
+type InMemoryDatabase struct {
+	lock                     sync.RWMutex
+	encounters               map[primitive.ObjectID]*model.Encounter
+	socialEncounters         map[primitive.ObjectID]*model.SocialEncounter
+	hiddenLocationEncounters map[primitive.ObjectID]*model.HiddenLocationEncounter
+	executions               map[primitive.ObjectID]*model.EncounterExecution
+	xpEntries                []*model.XpEntry
+	students                 map[uuid.UUID]*model.Student
+}
+
+func NewInMemoryDatabase() *InMemoryDatabase {
+	return &InMemoryDatabase{
+		encounters:               map[primitive.ObjectID]*model.Encounter{},
+		socialEncounters:         map[primitive.ObjectID]*model.SocialEncounter{},
+		hiddenLocationEncounters: map[primitive.ObjectID]*model.HiddenLocationEncounter{},
+		executions:               map[primitive.ObjectID]*model.EncounterExecution{},
+		students:                 map[uuid.UUID]*model.Student{},
+	}
+}
+
+func cloneEncounter(encounter *model.Encounter) *model.Encounter {
+	clone := *encounter
+	if encounter.Approval != nil {
+		approval := *encounter.Approval
+		if approval.ReviewedAt != nil {
+			reviewedAt := *approval.ReviewedAt
+			approval.ReviewedAt = &reviewedAt
+		}
+		clone.Approval = &approval
+	}
+	if encounter.Location != nil {
+		clone.Location = model.NewGeoPoint(encounter.Location.Coordinates[1], encounter.Location.Coordinates[0])
+	}
+	if encounter.DeletedAt != nil {
+		deletedAt := *encounter.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
+
+func cloneSocialEncounter(encounter *model.SocialEncounter) *model.SocialEncounter {
+	clone := *encounter
+	clone.TouristIDs = append([]int{}, encounter.TouristIDs...)
+	if encounter.DeletedAt != nil {
+		deletedAt := *encounter.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
+
+func cloneHiddenLocationEncounter(encounter *model.HiddenLocationEncounter) *model.HiddenLocationEncounter {
+	clone := *encounter
+	if encounter.DeletedAt != nil {
+		deletedAt := *encounter.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
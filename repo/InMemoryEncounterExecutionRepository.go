@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ EncounterExecutionRepository = (*InMemoryEncounterExecutionRepository)(nil)
+
+type InMemoryEncounterExecutionRepository struct {
+	Database *InMemoryDatabase
+}
+
+// FindByUserId returns the most recently started execution of the user.
+func (r *InMemoryEncounterExecutionRepository) FindByUserId(ctx context.Context, userID int) (model.EncounterExecution, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	executions := sortedByID(r.Database.executions)
+	for i := len(executions) - 1; i >= 0; i-- {
+		if executions[i].UserID == userID {
+			return *executions[i], nil
+		}
+	}
+	return model.EncounterExecution{}, ErrExecutionNotFound
+}
+
+func (r *InMemoryEncounterExecutionRepository) FindByUserAndEncounter(ctx context.Context, userID int, encounterID primitive.ObjectID) (model.EncounterExecution, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	for _, execution := range sortedByID(r.Database.executions) {
+		if execution.UserID == userID && execution.EncounterID == encounterID {
+			return *execution, nil
+		}
+	}
+	return model.EncounterExecution{}, ErrExecutionNotFound
+}
+
+func (r *InMemoryEncounterExecutionRepository) Update(ctx context.Context, execution *model.EncounterExecution) error {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	if _, ok := r.Database.executions[execution.ID]; !ok {
+		return ErrExecutionNotFound
+	}
+	stored := *execution
+	r.Database.executions[execution.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryEncounterExecutionRepository) Create(ctx context.Context, execution *model.EncounterExecution) error {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	for _, existing := range r.Database.executions {
+		if existing.UserID == execution.UserID && existing.EncounterID == execution.EncounterID {
+			return ErrExecutionExists
+		}
+	}
+
+	execution.ID = primitive.NewObjectID()
+	stored := *execution
+	r.Database.executions[execution.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryEncounterExecutionRepository) Delete(ctx context.Context, executionID string) error {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	objectID, err := primitive.ObjectIDFromHex(executionID)
+	if err != nil {
+		return ErrExecutionNotFound
+	}
+	if _, ok := r.Database.executions[objectID]; !ok {
+		return ErrExecutionNotFound
+	}
+	delete(r.Database.executions, objectID)
+	return nil
+}
+
+func (r *InMemoryEncounterExecutionRepository) GetAll(ctx context.Context) ([]*model.EncounterExecution, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	var executions []*model.EncounterExecution
+	for _, execution := range sortedByID(r.Database.executions) {
+		stored := *execution
+		executions = append(executions, &stored)
+	}
+	return executions, nil
+}
@@ -0,0 +1,659 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"database-example/model"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ EncounterRepository = (*InMemoryEncounterRepository)(nil)
+
+// InMemoryEncounterRepository is an EncounterRepository with the same behavior as
+// MongoEncounterRepository, for tests and local runs without a database.
+type InMemoryEncounterRepository struct {
+	Database *InMemoryDatabase
+}
+
+func (r *InMemoryEncounterRepository) CreateEncounter(ctx context.Context, encounter *model.Encounter) (*model.Encounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	r.insertEncounter(encounter)
+	return cloneEncounter(encounter), nil
+}
+
+func (r *InMemoryEncounterRepository) CreateEncounterWithDetails(ctx context.Context, details *model.EncounterDetails) (*model.EncounterDetails, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	r.insertEncounter(&details.Encounter)
+	if details.SocialEncounter != nil {
+		details.SocialEncounter.ID = primitive.NilObjectID
+		details.SocialEncounter.EncounterID = details.ID.Hex()
+		r.insertSocialEncounter(details.SocialEncounter)
+	}
+	if details.HiddenLocationEncounter != nil {
+		details.HiddenLocationEncounter.ID = primitive.NilObjectID
+		details.HiddenLocationEncounter.EncounterID = details.ID.Hex()
+		r.insertHiddenLocationEncounter(details.HiddenLocationEncounter)
+	}
+	return details, nil
+}
+
+func (r *InMemoryEncounterRepository) CreateSocialEncounter(ctx context.Context, encounter *model.SocialEncounter) error {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	r.insertSocialEncounter(encounter)
+	return nil
+}
+
+func (r *InMemoryEncounterRepository) CreateHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter) error {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	r.insertHiddenLocationEncounter(encounter)
+	return nil
+}
+
+func (r *InMemoryEncounterRepository) insertEncounter(encounter *model.Encounter) {
+	encounter.ID = primitive.NewObjectID()
+	encounter.Location = model.NewGeoPoint(encounter.Latitude, encounter.Longitude)
+	r.Database.encounters[encounter.ID] = cloneEncounter(encounter)
+}
+
+func (r *InMemoryEncounterRepository) insertSocialEncounter(encounter *model.SocialEncounter) {
+	if encounter.ID.IsZero() {
+		encounter.ID = primitive.NewObjectID()
+	}
+	if encounter.TouristIDs == nil {
+		encounter.TouristIDs = []int{}
+	}
+	r.Database.socialEncounters[encounter.ID] = cloneSocialEncounter(encounter)
+}
+
+func (r *InMemoryEncounterRepository) insertHiddenLocationEncounter(encounter *model.HiddenLocationEncounter) {
+	if encounter.ID.IsZero() {
+		encounter.ID = primitive.NewObjectID()
+	}
+	r.Database.hiddenLocationEncounters[encounter.ID] = cloneHiddenLocationEncounter(encounter)
+}
+
+func (r *InMemoryEncounterRepository) GetAllEncounters(ctx context.Context, viewerID int, encounterFilter model.EncounterFilter, page model.PageRequest) ([]*model.Encounter, string, error) {
+	matches := r.findEncounters(func(encounter *model.Encounter) bool {
+		return isVisibleTo(encounter, viewerID) && matchesFilter(encounter, encounterFilter)
+	})
+	return pageInMemory(matches, page, encounterSortFields, func(encounter *model.Encounter) primitive.ObjectID {
+		return encounter.ID
+	}, encounterSortValue)
+}
+
+func (r *InMemoryEncounterRepository) GetPendingEncounters(ctx context.Context) ([]*model.Encounter, error) {
+	return r.findEncounters(func(encounter *model.Encounter) bool {
+		return encounter.DeletedAt == nil && isPending(encounter)
+	}), nil
+}
+
+func (r *InMemoryEncounterRepository) GetEncountersByAuthor(ctx context.Context, authorID int) ([]*model.Encounter, error) {
+	return r.findEncounters(func(encounter *model.Encounter) bool {
+		return encounter.DeletedAt == nil && encounter.AuthorID == authorID
+	}), nil
+}
+
+func (r *InMemoryEncounterRepository) GetNearbyEncounters(ctx context.Context, latitude, longitude, radius float64, viewerID int) ([]*model.NearbyEncounter, error) {
+	candidates := r.findEncounters(func(encounter *model.Encounter) bool {
+		return encounter.Location != nil && isVisibleTo(encounter, viewerID)
+	})
+
+	var encounters []*model.NearbyEncounter
+	for _, encounter := range candidates {
+		distance := model.DistanceInMeters(latitude, longitude, encounter.Location.Coordinates[1], encounter.Location.Coordinates[0])
+		if distance <= radius {
+			encounters = append(encounters, &model.NearbyEncounter{Encounter: *encounter, Distance: distance})
+		}
+	}
+	sort.SliceStable(encounters, func(i, j int) bool {
+		return encounters[i].Distance < encounters[j].Distance
+	})
+	return encounters, nil
+}
+
+func (r *InMemoryEncounterRepository) GetAllHiddenLocationEncounters(ctx context.Context, viewerID int, page model.PageRequest) ([]*model.HiddenLocationEncounter, string, error) {
+	r.Database.lock.RLock()
+	var encounters []*model.HiddenLocationEncounter
+	for _, encounter := range r.Database.hiddenLocationEncounters {
+		if encounter.DeletedAt == nil && !r.hiddenFrom(encounter.EncounterID, viewerID) {
+			encounters = append(encounters, cloneHiddenLocationEncounter(encounter))
+		}
+	}
+	r.Database.lock.RUnlock()
+
+	return pageInMemory(encounters, page, subtypeSortFields, func(encounter *model.HiddenLocationEncounter) primitive.ObjectID {
+		return encounter.ID
+	}, func(*model.HiddenLocationEncounter, string) interface{} { return nil })
+}
+
+func (r *InMemoryEncounterRepository) GetAllSocialEncounters(ctx context.Context, viewerID int, page model.PageRequest) ([]*model.SocialEncounter, string, error) {
+	r.Database.lock.RLock()
+	var encounters []*model.SocialEncounter
+	for _, encounter := range r.Database.socialEncounters {
+		if encounter.DeletedAt == nil && !r.hiddenFrom(encounter.EncounterID, viewerID) {
+			encounters = append(encounters, cloneSocialEncounter(encounter))
+		}
+	}
+	r.Database.lock.RUnlock()
+
+	return pageInMemory(encounters, page, subtypeSortFields, func(encounter *model.SocialEncounter) primitive.ObjectID {
+		return encounter.ID
+	}, func(*model.SocialEncounter, string) interface{} { return nil })
+}
+
+func (r *InMemoryEncounterRepository) GetEncounterById(ctx context.Context, encounterID string) (*model.Encounter, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	encounter := r.encounter(encounterID)
+	if encounter == nil {
+		return nil, ErrEncounterNotFound
+	}
+	return cloneEncounter(encounter), nil
+}
+
+func (r *InMemoryEncounterRepository) GetHiddenLocationEncounterById(ctx context.Context, hiddenLocationEncounterID string) (*model.HiddenLocationEncounter, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	objectID, err := primitive.ObjectIDFromHex(hiddenLocationEncounterID)
+	if err != nil {
+		return nil, ErrEncounterNotFound
+	}
+	encounter, ok := r.Database.hiddenLocationEncounters[objectID]
+	if !ok || encounter.DeletedAt != nil {
+		return nil, ErrEncounterNotFound
+	}
+	return cloneHiddenLocationEncounter(encounter), nil
+}
+
+func (r *InMemoryEncounterRepository) GetSocialEncounterById(ctx context.Context, socialEncounterID string) (*model.SocialEncounter, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	objectID, err := primitive.ObjectIDFromHex(socialEncounterID)
+	if err != nil {
+		return nil, ErrEncounterNotFound
+	}
+	encounter, ok := r.Database.socialEncounters[objectID]
+	if !ok || encounter.DeletedAt != nil {
+		return nil, ErrEncounterNotFound
+	}
+	return cloneSocialEncounter(encounter), nil
+}
+
+func (r *InMemoryEncounterRepository) GetSocialEncounterByEncounterId(ctx context.Context, encounterID string) (*model.SocialEncounter, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	for _, encounter := range sortedByID(r.Database.socialEncounters) {
+		if encounter.EncounterID == encounterID && encounter.DeletedAt == nil {
+			return cloneSocialEncounter(encounter), nil
+		}
+	}
+	return nil, ErrEncounterNotFound
+}
+
+func (r *InMemoryEncounterRepository) GetHiddenLocationEncounterByEncounterId(ctx context.Context, encounterID string) (*model.HiddenLocationEncounter, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	for _, encounter := range sortedByID(r.Database.hiddenLocationEncounters) {
+		if encounter.EncounterID == encounterID && encounter.DeletedAt == nil {
+			return cloneHiddenLocationEncounter(encounter), nil
+		}
+	}
+	return nil, ErrEncounterNotFound
+}
+
+func (r *InMemoryEncounterRepository) ReviewEncounter(ctx context.Context, encounterID primitive.ObjectID, approval *model.EncounterApproval, status string) (bool, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	encounter, ok := r.Database.encounters[encounterID]
+	if !ok || encounter.DeletedAt != nil || !isPending(encounter) {
+		return false, nil
+	}
+	reviewed := *approval
+	encounter.Approval = &reviewed
+	encounter.Status = status
+	return true, nil
+}
+
+func (r *InMemoryEncounterRepository) UpdateStatus(ctx context.Context, encounterID primitive.ObjectID, expectedStatus string, status string) (bool, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	encounter, ok := r.Database.encounters[encounterID]
+	if !ok || encounter.DeletedAt != nil || encounter.Status != expectedStatus {
+		return false, nil
+	}
+	modified := encounter.Status != status
+	encounter.Status = status
+	return modified, nil
+}
+
+func (r *InMemoryEncounterRepository) CheckInTourist(ctx context.Context, socialEncounterID primitive.ObjectID, touristID int) (*model.SocialEncounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	encounter, ok := r.Database.socialEncounters[socialEncounterID]
+	if !ok || encounter.DeletedAt != nil {
+		return nil, ErrEncounterNotFound
+	}
+	for _, id := range encounter.TouristIDs {
+		if id == touristID {
+			return cloneSocialEncounter(encounter), nil
+		}
+	}
+	encounter.TouristIDs = append(encounter.TouristIDs, touristID)
+	return cloneSocialEncounter(encounter), nil
+}
+
+func (r *InMemoryEncounterRepository) CheckOutTourists(ctx context.Context, socialEncounterID primitive.ObjectID, touristIDs ...int) (*model.SocialEncounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	encounter, ok := r.Database.socialEncounters[socialEncounterID]
+	if !ok || encounter.DeletedAt != nil {
+		return nil, ErrEncounterNotFound
+	}
+	remaining := []int{}
+	for _, id := range encounter.TouristIDs {
+		if !containsInt(touristIDs, id) {
+			remaining = append(remaining, id)
+		}
+	}
+	encounter.TouristIDs = remaining
+	return cloneSocialEncounter(encounter), nil
+}
+
+func (r *InMemoryEncounterRepository) Update(ctx context.Context, encounter *model.Encounter, expectedStatus string) (*model.Encounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	stored, err := r.encounterInStatus(encounter.ID, expectedStatus)
+	if err != nil {
+		return nil, err
+	}
+	stored.Name = encounter.Name
+	stored.Description = encounter.Description
+	stored.XpPoints = encounter.XpPoints
+	stored.Status = encounter.Status
+	stored.Type = encounter.Type
+	stored.Longitude = encounter.Longitude
+	stored.Latitude = encounter.Latitude
+	stored.ShouldBeApproved = encounter.ShouldBeApproved
+	if encounter.Approval != nil {
+		approval := *encounter.Approval
+		stored.Approval = &approval
+	}
+	stored.Location = model.NewGeoPoint(encounter.Latitude, encounter.Longitude)
+	return cloneEncounter(stored), nil
+}
+
+func (r *InMemoryEncounterRepository) UpdateHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter) (*model.HiddenLocationEncounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	stored, ok := r.Database.hiddenLocationEncounters[encounter.ID]
+	if !ok || stored.DeletedAt != nil {
+		return nil, ErrEncounterNotFound
+	}
+	stored.ImageURL = encounter.ImageURL
+	stored.ImageLatitude = encounter.ImageLatitude
+	stored.ImageLongitude = encounter.ImageLongitude
+	stored.DistanceTreshold = encounter.DistanceTreshold
+	stored.EncounterID = encounter.EncounterID
+	return cloneHiddenLocationEncounter(stored), nil
+}
+
+func (r *InMemoryEncounterRepository) UpdateSocialEncounter(ctx context.Context, encounter *model.SocialEncounter) (*model.SocialEncounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	stored, ok := r.Database.socialEncounters[encounter.ID]
+	if !ok || stored.DeletedAt != nil {
+		return nil, ErrEncounterNotFound
+	}
+	stored.EncounterID = encounter.EncounterID
+	stored.TouristsRequiredForCompletion = encounter.TouristsRequiredForCompletion
+	stored.DistanceTreshold = encounter.DistanceTreshold
+	return cloneSocialEncounter(stored), nil
+}
+
+func (r *InMemoryEncounterRepository) PatchEncounter(ctx context.Context, encounter *model.Encounter, fields model.PatchedFields, expectedStatus string) (*model.Encounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	stored, err := r.encounterInStatus(encounter.ID, expectedStatus)
+	if err != nil {
+		return nil, err
+	}
+	if err := patchStored(stored, encounter, fields); err != nil {
+		return nil, err
+	}
+	stored.Location = model.NewGeoPoint(stored.Latitude, stored.Longitude)
+	return cloneEncounter(stored), nil
+}
+
+// encounterInStatus returns the stored encounter that is not in the trash, checking
+// its status unless expectedStatus is empty. The caller holds the lock.
+func (r *InMemoryEncounterRepository) encounterInStatus(id primitive.ObjectID, expectedStatus string) (*model.Encounter, error) {
+	stored, ok := r.Database.encounters[id]
+	if !ok || stored.DeletedAt != nil {
+		return nil, ErrEncounterNotFound
+	}
+	if expectedStatus != "" && stored.Status != expectedStatus {
+		return nil, ErrStatusChanged
+	}
+	return stored, nil
+}
+
+func (r *InMemoryEncounterRepository) PatchHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter, fields model.PatchedFields) (*model.HiddenLocationEncounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	stored, ok := r.Database.hiddenLocationEncounters[encounter.ID]
+	if !ok || stored.DeletedAt != nil {
+		return nil, ErrEncounterNotFound
+	}
+	if err := patchStored(stored, encounter, fields); err != nil {
+		return nil, err
+	}
+	return cloneHiddenLocationEncounter(stored), nil
+}
+
+func (r *InMemoryEncounterRepository) PatchSocialEncounter(ctx context.Context, encounter *model.SocialEncounter, fields model.PatchedFields) (*model.SocialEncounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	stored, ok := r.Database.socialEncounters[encounter.ID]
+	if !ok || stored.DeletedAt != nil {
+		return nil, ErrEncounterNotFound
+	}
+	if err := patchStored(stored, encounter, fields); err != nil {
+		return nil, err
+	}
+	return cloneSocialEncounter(stored), nil
+}
+
+// patchStored copies the patched fields, by their JSON names, from merged to stored
+// and leaves the other fields of stored as they are.
+func patchStored[T any](stored, merged *T, fields model.PatchedFields) error {
+	var storedFields, mergedFields map[string]json.RawMessage
+	if err := remarshal(stored, &storedFields); err != nil {
+		return err
+	}
+	if err := remarshal(merged, &mergedFields); err != nil {
+		return err
+	}
+	for _, field := range fields.Set {
+		storedFields[field] = mergedFields[field]
+	}
+	for _, field := range fields.Unset {
+		delete(storedFields, field)
+	}
+
+	var patched T
+	if err := remarshal(storedFields, &patched); err != nil {
+		return err
+	}
+	*stored = patched
+	return nil
+}
+
+func remarshal(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+func (r *InMemoryEncounterRepository) SoftDeleteEncounter(ctx context.Context, baseEncounterID string, deletedBy int) error {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	encounter := r.encounter(baseEncounterID)
+	if encounter == nil {
+		return ErrEncounterNotFound
+	}
+
+	deletedAt := time.Now().UTC()
+	encounter.DeletedAt, encounter.DeletedBy = &deletedAt, deletedBy
+	for _, social := range r.Database.socialEncounters {
+		if social.EncounterID == baseEncounterID && social.DeletedAt == nil {
+			social.DeletedAt, social.DeletedBy = &deletedAt, deletedBy
+		}
+	}
+	for _, hidden := range r.Database.hiddenLocationEncounters {
+		if hidden.EncounterID == baseEncounterID && hidden.DeletedAt == nil {
+			hidden.DeletedAt, hidden.DeletedBy = &deletedAt, deletedBy
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryEncounterRepository) RestoreEncounter(ctx context.Context, baseEncounterID string) (*model.Encounter, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	objectID, err := primitive.ObjectIDFromHex(baseEncounterID)
+	if err != nil {
+		return nil, ErrEncounterNotFound
+	}
+	encounter, ok := r.Database.encounters[objectID]
+	if !ok || encounter.DeletedAt == nil {
+		return nil, ErrEncounterNotFound
+	}
+
+	encounter.DeletedAt, encounter.DeletedBy = nil, 0
+	for _, social := range r.Database.socialEncounters {
+		if social.EncounterID == baseEncounterID {
+			social.DeletedAt, social.DeletedBy = nil, 0
+		}
+	}
+	for _, hidden := range r.Database.hiddenLocationEncounters {
+		if hidden.EncounterID == baseEncounterID {
+			hidden.DeletedAt, hidden.DeletedBy = nil, 0
+		}
+	}
+	return cloneEncounter(encounter), nil
+}
+
+func (r *InMemoryEncounterRepository) GetDeletedEncounters(ctx context.Context) ([]*model.Encounter, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	var encounters []*model.Encounter
+	for _, encounter := range sortedByID(r.Database.encounters) {
+		if encounter.DeletedAt != nil {
+			encounters = append(encounters, cloneEncounter(encounter))
+		}
+	}
+	return encounters, nil
+}
+
+func (r *InMemoryEncounterRepository) GetEncounterIdsDeletedBefore(ctx context.Context, before time.Time) ([]string, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	ids := []string{}
+	for _, encounter := range sortedByID(r.Database.encounters) {
+		if encounter.DeletedAt != nil && encounter.DeletedAt.Before(before) {
+			ids = append(ids, encounter.ID.Hex())
+		}
+	}
+	return ids, nil
+}
+
+func (r *InMemoryEncounterRepository) PurgeEncounter(ctx context.Context, baseEncounterID string, deletedBefore time.Time) (*model.EncounterDeletion, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	objectID, err := primitive.ObjectIDFromHex(baseEncounterID)
+	if err != nil {
+		return nil, ErrEncounterNotFound
+	}
+	encounter, ok := r.Database.encounters[objectID]
+	if !ok || encounter.DeletedAt == nil || !encounter.DeletedAt.Before(deletedBefore) {
+		return nil, ErrEncounterNotFound
+	}
+
+	deletion := &model.EncounterDeletion{Encounters: 1}
+	delete(r.Database.encounters, objectID)
+	for id, social := range r.Database.socialEncounters {
+		if social.EncounterID == baseEncounterID {
+			delete(r.Database.socialEncounters, id)
+			deletion.SocialEncounters++
+		}
+	}
+	for id, hidden := range r.Database.hiddenLocationEncounters {
+		if hidden.EncounterID == baseEncounterID {
+			delete(r.Database.hiddenLocationEncounters, id)
+			deletion.HiddenLocationEncounters++
+		}
+	}
+	for id, execution := range r.Database.executions {
+		if execution.EncounterID == objectID {
+			delete(r.Database.executions, id)
+			deletion.Executions++
+		}
+	}
+	return deletion, nil
+}
+
+// encounter returns the stored encounter, or nil if it does not exist or is in the trash.
+// The caller must hold the lock.
+func (r *InMemoryEncounterRepository) encounter(encounterID string) *model.Encounter {
+	objectID, err := primitive.ObjectIDFromHex(encounterID)
+	if err != nil {
+		return nil
+	}
+	encounter, ok := r.Database.encounters[objectID]
+	if !ok || encounter.DeletedAt != nil {
+		return nil
+	}
+	return encounter
+}
+
+// hiddenFrom reports whether the encounter a part belongs to is hidden from the viewer,
+// mirroring partsVisibleToFilter. The caller holds the lock.
+func (r *InMemoryEncounterRepository) hiddenFrom(encounterID string, viewerID int) bool {
+	encounter := r.encounter(encounterID)
+	return encounter != nil && !isVisibleTo(encounter, viewerID)
+}
+
+func (r *InMemoryEncounterRepository) findEncounters(matches func(*model.Encounter) bool) []*model.Encounter {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	var encounters []*model.Encounter
+	for _, encounter := range sortedByID(r.Database.encounters) {
+		if matches(encounter) {
+			encounters = append(encounters, cloneEncounter(encounter))
+		}
+	}
+	return encounters
+}
+
+// isVisibleTo mirrors visibleToFilter.
+func isVisibleTo(encounter *model.Encounter, viewerID int) bool {
+	if encounter.DeletedAt != nil {
+		return false
+	}
+	return encounter.IsApproved() || (viewerID != 0 && encounter.AuthorID == viewerID)
+}
+
+func isPending(encounter *model.Encounter) bool {
+	return encounter.ShouldBeApproved && (encounter.Approval == nil || encounter.Approval.Decision == model.ApprovalPending)
+}
+
+// matchesFilter mirrors the query built by MongoEncounterRepository.GetAllEncounters.
+func matchesFilter(encounter *model.Encounter, filter model.EncounterFilter) bool {
+	if filter.Status != "" && !strings.EqualFold(encounter.Status, filter.Status) {
+		return false
+	}
+	if filter.Type != "" && !strings.EqualFold(encounter.Type, filter.Type) {
+		return false
+	}
+	if filter.MinXp != nil && encounter.XpPoints < *filter.MinXp {
+		return false
+	}
+	if filter.MaxXp != nil && encounter.XpPoints > *filter.MaxXp {
+		return false
+	}
+	if filter.Name != "" && !strings.Contains(strings.ToLower(encounter.Name), strings.ToLower(filter.Name)) {
+		return false
+	}
+	if box := filter.BoundingBox; box != nil {
+		if encounter.Latitude < box.MinLatitude || encounter.Latitude > box.MaxLatitude {
+			return false
+		}
+		if box.MinLongitude <= box.MaxLongitude {
+			return encounter.Longitude >= box.MinLongitude && encounter.Longitude <= box.MaxLongitude
+		}
+		return encounter.Longitude >= box.MinLongitude || encounter.Longitude <= box.MaxLongitude
+	}
+	return true
+}
+
+// encounterSortValue returns the value of one of the encounterSortFields bson keys.
+func encounterSortValue(encounter *model.Encounter, field string) interface{} {
+	switch field {
+	case "name":
+		return encounter.Name
+	case "xpPoints":
+		return encounter.XpPoints
+	case "status":
+		return encounter.Status
+	case "type":
+		return encounter.Type
+	case "latitude":
+		return encounter.Latitude
+	case "longitude":
+		return encounter.Longitude
+	}
+	return nil
+}
+
+// sortedByID returns the documents in insertion order, which is what Mongo
+// returns for unsorted queries on this data.
+func sortedByID[T any](documents map[primitive.ObjectID]*T) []*T {
+	ids := make([]primitive.ObjectID, 0, len(documents))
+	for id := range documents {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+
+	sorted := make([]*T, 0, len(ids))
+	for _, id := range ids {
+		sorted = append(sorted, documents[id])
+	}
+	return sorted
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+
+	"github.com/google/uuid"
+)
+
+var _ StudentRepository = (*InMemoryStudentRepository)(nil)
+
+type InMemoryStudentRepository struct {
+	Database *InMemoryDatabase
+}
+
+func (r *InMemoryStudentRepository) FindById(ctx context.Context, id string) (model.Student, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	studentID, err := uuid.Parse(id)
+	if err != nil {
+		return model.Student{}, ErrStudentNotFound
+	}
+	student, ok := r.Database.students[studentID]
+	if !ok {
+		return model.Student{}, ErrStudentNotFound
+	}
+	return *student, nil
+}
+
+func (r *InMemoryStudentRepository) CreateStudent(ctx context.Context, student *model.Student) error {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	// Isto sto radi BeforeCreate hook u GORM-u
+	student.ID = uuid.New()
+	stored := *student
+	r.Database.students[student.ID] = &stored
+	return nil
+}
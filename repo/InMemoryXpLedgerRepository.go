@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var _ XpLedgerRepository = (*InMemoryXpLedgerRepository)(nil)
+
+type InMemoryXpLedgerRepository struct {
+	Database *InMemoryDatabase
+}
+
+// Append records the entry and reports whether it was added. An entry for an
+// execution, or for an encounter the user has already been awarded, is ignored.
+func (r *InMemoryXpLedgerRepository) Append(ctx context.Context, entry *model.XpEntry) (bool, error) {
+	r.Database.lock.Lock()
+	defer r.Database.lock.Unlock()
+
+	entry.ID = primitive.NewObjectID()
+	for _, existing := range r.Database.xpEntries {
+		if existing.ExecutionID == entry.ExecutionID || (existing.UserID == entry.UserID && existing.EncounterID == entry.EncounterID) {
+			return false, nil
+		}
+	}
+	stored := *entry
+	r.Database.xpEntries = append(r.Database.xpEntries, &stored)
+	return true, nil
+}
+
+func (r *InMemoryXpLedgerRepository) FindByUserId(ctx context.Context, userID int) ([]*model.XpEntry, error) {
+	r.Database.lock.RLock()
+	defer r.Database.lock.RUnlock()
+
+	entries := []*model.XpEntry{}
+	for _, entry := range r.Database.xpEntries {
+		if entry.UserID == userID {
+			stored := *entry
+			entries = append(entries, &stored)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].AwardedAt.Before(entries[j].AwardedAt)
+	})
+	return entries, nil
+}
@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var _ EncounterExecutionRepository = (*MongoEncounterExecutionRepository)(nil)
+
+type MongoEncounterExecutionRepository struct {
+	DatabaseConnection *mongo.Client
+	// DatabaseName defaults to DefaultDatabaseName.
+	DatabaseName string
+}
+
+func (repo *MongoEncounterExecutionRepository) collection() *mongo.Collection {
+	return mongoDatabase(repo.DatabaseConnection, repo.DatabaseName).Collection("encounterExecutions")
+}
+
+// EnsureIndexes creates the unique index that keeps a tourist from activating
+// the same encounter twice.
+func (repo *MongoEncounterExecutionRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "MongoEncounterExecutionRepository.EnsureIndexes")
+	defer span.End()
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "encounterId", Value: 1}},
+		Options: options.Index().SetName(userIdEncounterIdIndexName).SetUnique(true),
+	}
+	_, err := repo.collection().Indexes().CreateOne(ctx, index)
+	return err
+}
+
+// FindByUserId returns the most recently started execution of the user.
+func (repo *MongoEncounterExecutionRepository) FindByUserId(ctx context.Context, userID int) (model.EncounterExecution, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterExecutionRepository.FindByUserId")
+	defer span.End()
+
+	execution := model.EncounterExecution{}
+	filter := bson.M{"userId": userID}
+	opts := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})
+
+	err := repo.collection().FindOne(ctx, filter, opts).Decode(&execution)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return execution, ErrExecutionNotFound
+		}
+		return execution, err
+	}
+	return execution, nil
+}
+
+func (repo *MongoEncounterExecutionRepository) FindByUserAndEncounter(ctx context.Context, userID int, encounterID primitive.ObjectID) (model.EncounterExecution, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterExecutionRepository.FindByUserAndEncounter")
+	defer span.End()
+
+	execution := model.EncounterExecution{}
+	filter := bson.M{"userId": userID, "encounterId": encounterID}
+
+	err := repo.collection().FindOne(ctx, filter).Decode(&execution)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return execution, ErrExecutionNotFound
+		}
+		return execution, err
+	}
+	return execution, nil
+}
+
+func (repo *MongoEncounterExecutionRepository) Update(ctx context.Context, execution *model.EncounterExecution) error {
+	ctx, span := tracer.Start(ctx, "MongoEncounterExecutionRepository.Update")
+	defer span.End()
+
+	filter := bson.M{"_id": execution.ID}
+
+	result, err := repo.collection().ReplaceOne(ctx, filter, execution)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrExecutionNotFound
+	}
+	return nil
+}
+
+func (repo *MongoEncounterExecutionRepository) Create(ctx context.Context, execution *model.EncounterExecution) error {
+	ctx, span := tracer.Start(ctx, "MongoEncounterExecutionRepository.Create")
+	defer span.End()
+
+	execution.ID = primitive.NewObjectID()
+
+	_, err := repo.collection().InsertOne(ctx, execution)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrExecutionExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (repo *MongoEncounterExecutionRepository) Delete(ctx context.Context, executionID string) error {
+	ctx, span := tracer.Start(ctx, "MongoEncounterExecutionRepository.Delete")
+	defer span.End()
+
+	objectID, err := primitive.ObjectIDFromHex(executionID)
+	if err != nil {
+		return ErrExecutionNotFound
+	}
+
+	result, err := repo.collection().DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrExecutionNotFound
+	}
+	return nil
+}
+
+func (repo *MongoEncounterExecutionRepository) GetAll(ctx context.Context) ([]*model.EncounterExecution, error) {
+
+	ctx, span := tracer.Start(ctx, "MongoEncounterExecutionRepository.GetAll")
+	defer span.End()
+
+	cursor, err := repo.collection().Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var executions []*model.EncounterExecution
+	for cursor.Next(ctx) {
+		var execution model.EncounterExecution
+		if err := cursor.Decode(&execution); err != nil {
+			return nil, err
+		}
+
+		executions = append(executions, &execution)
+	}
+
+	return executions, nil
+}
@@ -0,0 +1,907 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"errors"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// notDeleted matches documents that are not in the trash.
+var notDeleted = bson.M{"$exists": false}
+
+var _ EncounterRepository = (*MongoEncounterRepository)(nil)
+
+type MongoEncounterRepository struct {
+	//DatabaseConnection *gorm.DB //za konekciju sa bazom podataka
+	DatabaseConnection *mongo.Client
+	// DatabaseName defaults to DefaultDatabaseName.
+	DatabaseName string
+}
+
+func (r *MongoEncounterRepository) database() *mongo.Database {
+	return mongoDatabase(r.DatabaseConnection, r.DatabaseName)
+}
+
+func (repo *MongoEncounterRepository) CreateEncounter(ctx context.Context, encounter *model.Encounter) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.CreateEncounter")
+	defer span.End()
+
+	collection := repo.database().Collection("encounters")
+
+	encounter.ID = primitive.NewObjectID()
+	encounter.Location = model.NewGeoPoint(encounter.Latitude, encounter.Longitude)
+	_, err := collection.InsertOne(ctx, encounter)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.D{{Key: "_id", Value: encounter.ID}}
+
+	var createdEncounter model.Encounter
+	err = collection.FindOne(ctx, filter).Decode(&createdEncounter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &createdEncounter, nil
+}
+
+// CreateEncounterWithDetails inserts the encounter and its social or hidden location part
+// in one transaction, so a failure leaves neither of them behind.
+func (repo *MongoEncounterRepository) CreateEncounterWithDetails(ctx context.Context, details *model.EncounterDetails) (*model.EncounterDetails, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.CreateEncounterWithDetails")
+	defer span.End()
+
+	database := repo.database()
+
+	err := repo.inTransaction(ctx, func(sessionCtx mongo.SessionContext) error {
+		details.ID = primitive.NewObjectID()
+		details.Location = model.NewGeoPoint(details.Latitude, details.Longitude)
+		if _, err := database.Collection("encounters").InsertOne(sessionCtx, &details.Encounter); err != nil {
+			return err
+		}
+
+		if details.SocialEncounter != nil {
+			details.SocialEncounter.ID = primitive.NewObjectID()
+			details.SocialEncounter.EncounterID = details.ID.Hex()
+			if details.SocialEncounter.TouristIDs == nil {
+				details.SocialEncounter.TouristIDs = []int{}
+			}
+			if _, err := database.Collection("socialEncounters").InsertOne(sessionCtx, details.SocialEncounter); err != nil {
+				return err
+			}
+		}
+
+		if details.HiddenLocationEncounter != nil {
+			details.HiddenLocationEncounter.ID = primitive.NewObjectID()
+			details.HiddenLocationEncounter.EncounterID = details.ID.Hex()
+			if _, err := database.Collection("hiddenLocationEncounters").InsertOne(sessionCtx, details.HiddenLocationEncounter); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// inTransaction runs fn in a multi-document transaction, which needs Mongo to run as a
+// replica set. WithTransaction retries fn on transient errors, so fn must be repeatable.
+func (repo *MongoEncounterRepository) inTransaction(ctx context.Context, fn func(sessionCtx mongo.SessionContext) error) error {
+	session, err := repo.DatabaseConnection.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
+func (repo *MongoEncounterRepository) CreateSocialEncounter(ctx context.Context, encounter *model.SocialEncounter) error {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.CreateSocialEncounter")
+	defer span.End()
+
+	collection := repo.database().Collection("socialEncounters")
+
+	if encounter.ID.IsZero() {
+		encounter.ID = primitive.NewObjectID()
+	}
+	if encounter.TouristIDs == nil {
+		encounter.TouristIDs = []int{}
+	}
+
+	_, err := collection.InsertOne(ctx, encounter)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (repo *MongoEncounterRepository) CreateHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter) error {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.CreateHiddenLocationEncounter")
+	defer span.End()
+
+	collection := repo.database().Collection("hiddenLocationEncounters")
+
+	if encounter.ID.IsZero() {
+		encounter.ID = primitive.NewObjectID()
+	}
+
+	_, err := collection.InsertOne(ctx, encounter)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// encounterSortFields maps the JSON names encounters can be sorted by to their bson keys.
+var encounterSortFields = map[string]string{
+	"id":        "_id",
+	"name":      "name",
+	"xpPoints":  "xpPoints",
+	"status":    "status",
+	"type":      "type",
+	"latitude":  "latitude",
+	"longitude": "longitude",
+}
+
+var subtypeSortFields = map[string]string{
+	"id": "_id",
+}
+
+// GetAllEncounters returns one page of the encounters matching the filter that the viewer
+// may see: everything that needs no approval or has been approved, plus the viewer's own
+// proposals. The second result is the cursor of the next page.
+func (r *MongoEncounterRepository) GetAllEncounters(ctx context.Context, viewerID int, encounterFilter model.EncounterFilter, page model.PageRequest) ([]*model.Encounter, string, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetAllEncounters")
+	defer span.End()
+
+	filter := visibleToFilter(viewerID)
+	conditions := bson.A{}
+
+	// Stariji susreti nemaju uvek normalizovana velika i mala slova
+	if encounterFilter.Status != "" {
+		filter["status"] = bson.M{"$regex": "^" + regexp.QuoteMeta(encounterFilter.Status) + "$", "$options": "i"}
+	}
+	if encounterFilter.Type != "" {
+		filter["type"] = bson.M{"$regex": "^" + regexp.QuoteMeta(encounterFilter.Type) + "$", "$options": "i"}
+	}
+	if encounterFilter.MinXp != nil || encounterFilter.MaxXp != nil {
+		xpRange := bson.M{}
+		if encounterFilter.MinXp != nil {
+			xpRange["$gte"] = *encounterFilter.MinXp
+		}
+		if encounterFilter.MaxXp != nil {
+			xpRange["$lte"] = *encounterFilter.MaxXp
+		}
+		filter["xpPoints"] = xpRange
+	}
+	if encounterFilter.Name != "" {
+		filter["name"] = bson.M{"$regex": regexp.QuoteMeta(encounterFilter.Name), "$options": "i"}
+	}
+	if box := encounterFilter.BoundingBox; box != nil {
+		filter["latitude"] = bson.M{"$gte": box.MinLatitude, "$lte": box.MaxLatitude}
+		if box.MinLongitude <= box.MaxLongitude {
+			filter["longitude"] = bson.M{"$gte": box.MinLongitude, "$lte": box.MaxLongitude}
+		} else {
+			// Okvir prelazi 180. meridijan
+			conditions = append(conditions, bson.M{"$or": bson.A{
+				bson.M{"longitude": bson.M{"$gte": box.MinLongitude}},
+				bson.M{"longitude": bson.M{"$lte": box.MaxLongitude}},
+			}})
+		}
+	}
+	if len(conditions) > 0 {
+		filter["$and"] = conditions
+	}
+
+	collection := r.database().Collection("encounters")
+	return findPage[model.Encounter](ctx, collection, filter, page, encounterSortFields)
+}
+
+func (r *MongoEncounterRepository) GetPendingEncounters(ctx context.Context) ([]*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetPendingEncounters")
+	defer span.End()
+
+	filter := bson.M{
+		"shouldBeApproved": true,
+		"$or": bson.A{
+			bson.M{"approval": bson.M{"$exists": false}},
+			bson.M{"approval.decision": model.ApprovalPending},
+		},
+		"deletedAt": notDeleted,
+	}
+	return r.findEncounters(ctx, filter)
+}
+
+func (r *MongoEncounterRepository) GetEncountersByAuthor(ctx context.Context, authorID int) ([]*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetEncountersByAuthor")
+	defer span.End()
+
+	return r.findEncounters(ctx, bson.M{"authorId": authorID, "deletedAt": notDeleted})
+}
+
+// ReviewEncounter stores the approval decision and new status, but only while the
+// encounter is still waiting for review. It reports whether the encounter was updated.
+func (r *MongoEncounterRepository) ReviewEncounter(ctx context.Context, encounterID primitive.ObjectID, approval *model.EncounterApproval, status string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.ReviewEncounter")
+	defer span.End()
+
+	filter := bson.M{
+		"_id":              encounterID,
+		"shouldBeApproved": true,
+		"$or": bson.A{
+			bson.M{"approval": bson.M{"$exists": false}},
+			bson.M{"approval.decision": model.ApprovalPending},
+		},
+		"deletedAt": notDeleted,
+	}
+	update := bson.M{"$set": bson.M{"approval": approval, "status": status}}
+
+	result, err := r.database().Collection("encounters").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *MongoEncounterRepository) findEncounters(ctx context.Context, filter interface{}) ([]*model.Encounter, error) {
+	cursor, err := r.database().Collection("encounters").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var encounters []*model.Encounter
+	for cursor.Next(ctx) {
+		var encounter model.Encounter
+		if err := cursor.Decode(&encounter); err != nil {
+			return nil, err
+		}
+
+		encounters = append(encounters, &encounter)
+	}
+
+	return encounters, nil
+}
+
+func visibleToFilter(viewerID int) bson.M {
+	return bson.M{"$or": visibleConditions(viewerID), "deletedAt": notDeleted}
+}
+
+// visibleConditions are the alternatives of which an encounter the viewer may see matches one.
+func visibleConditions(viewerID int) bson.A {
+	visible := bson.A{
+		bson.M{"shouldBeApproved": false},
+		bson.M{"approval.decision": model.ApprovalApproved},
+	}
+	if viewerID != 0 {
+		visible = append(visible, bson.M{"authorId": viewerID})
+	}
+	return visible
+}
+
+// partsVisibleToFilter matches the social and hidden location parts that are not in the
+// trash and do not belong to an encounter hidden from the viewer. Hidden encounters are
+// the proposals of other authors still waiting for approval or rejected, so there are few.
+func (r *MongoEncounterRepository) partsVisibleToFilter(ctx context.Context, viewerID int) (bson.M, error) {
+	filter := bson.M{"$nor": visibleConditions(viewerID), "deletedAt": notDeleted}
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := r.database().Collection("encounters").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	hidden := bson.A{}
+	for cursor.Next(ctx) {
+		hidden = append(hidden, cursor.Current.Lookup("_id").ObjectID().Hex())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return bson.M{"encounterId": bson.M{"$nin": hidden}, "deletedAt": notDeleted}, nil
+}
+
+// EnsureLocationIndex fills in the GeoJSON location for encounters stored
+// before it was introduced and creates the 2dsphere index used by GetNearbyEncounters.
+func (r *MongoEncounterRepository) EnsureLocationIndex(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.EnsureLocationIndex")
+	defer span.End()
+
+	collection := r.database().Collection("encounters")
+
+	filter := bson.M{
+		"location":  bson.M{"$exists": false},
+		"latitude":  bson.M{"$gte": -90, "$lte": 90},
+		"longitude": bson.M{"$gte": -180, "$lte": 180},
+	}
+	backfill := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"location": bson.M{
+				"type":        "Point",
+				"coordinates": bson.A{"$longitude", "$latitude"},
+			},
+		}}},
+	}
+	_, err := collection.UpdateMany(ctx, filter, backfill)
+	if err != nil {
+		return err
+	}
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "location", Value: "2dsphere"}},
+		Options: options.Index().SetName(locationIndexName),
+	}
+	_, err = collection.Indexes().CreateOne(ctx, index)
+	return err
+}
+
+// GetNearbyEncounters returns encounters visible to the viewer within radius meters
+// of the given point, closest first, with the distance in meters filled in.
+func (r *MongoEncounterRepository) GetNearbyEncounters(ctx context.Context, latitude, longitude, radius float64, viewerID int) ([]*model.NearbyEncounter, error) {
+
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetNearbyEncounters")
+	defer span.End()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.D{
+			{Key: "near", Value: model.NewGeoPoint(latitude, longitude)},
+			{Key: "distanceField", Value: "distance"},
+			{Key: "maxDistance", Value: radius},
+			{Key: "spherical", Value: true},
+			{Key: "query", Value: visibleToFilter(viewerID)},
+		}}},
+	}
+
+	cursor, err := r.database().Collection("encounters").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var encounters []*model.NearbyEncounter
+	for cursor.Next(ctx) {
+		var encounter model.NearbyEncounter
+		if err := cursor.Decode(&encounter); err != nil {
+			return nil, err
+		}
+
+		encounters = append(encounters, &encounter)
+	}
+
+	return encounters, cursor.Err()
+}
+
+func (r *MongoEncounterRepository) GetAllHiddenLocationEncounters(ctx context.Context, viewerID int, page model.PageRequest) ([]*model.HiddenLocationEncounter, string, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetAllHiddenLocationEncounters")
+	defer span.End()
+
+	filter, err := r.partsVisibleToFilter(ctx, viewerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	collection := r.database().Collection("hiddenLocationEncounters")
+	return findPage[model.HiddenLocationEncounter](ctx, collection, filter, page, subtypeSortFields)
+}
+
+func (r *MongoEncounterRepository) GetAllSocialEncounters(ctx context.Context, viewerID int, page model.PageRequest) ([]*model.SocialEncounter, string, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetAllSocialEncounters")
+	defer span.End()
+
+	filter, err := r.partsVisibleToFilter(ctx, viewerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	collection := r.database().Collection("socialEncounters")
+	return findPage[model.SocialEncounter](ctx, collection, filter, page, subtypeSortFields)
+}
+
+func (r *MongoEncounterRepository) GetEncounterById(ctx context.Context, encounterID string) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetEncounterById")
+	defer span.End()
+
+	objectID, err := primitive.ObjectIDFromHex(encounterID)
+	if err != nil {
+		return nil, ErrEncounterNotFound
+	}
+
+	filter := bson.M{"_id": objectID, "deletedAt": notDeleted}
+
+	var encounter model.Encounter
+	err = r.database().Collection("encounters").FindOne(ctx, filter).Decode(&encounter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEncounterNotFound
+		}
+		return nil, err
+	}
+
+	return &encounter, nil
+}
+
+func (r *MongoEncounterRepository) GetHiddenLocationEncounterById(ctx context.Context, hiddenLocationEncounterID string) (*model.HiddenLocationEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetHiddenLocationEncounterById")
+	defer span.End()
+
+	objectID, err := primitive.ObjectIDFromHex(hiddenLocationEncounterID)
+	if err != nil {
+		return nil, ErrEncounterNotFound
+	}
+
+	filter := bson.M{"_id": objectID, "deletedAt": notDeleted}
+
+	var encounter model.HiddenLocationEncounter
+	err = r.database().Collection("hiddenLocationEncounters").FindOne(ctx, filter).Decode(&encounter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEncounterNotFound
+		}
+		return nil, err
+	}
+
+	return &encounter, nil
+}
+
+func (r *MongoEncounterRepository) GetSocialEncounterById(ctx context.Context, socialEncounterID string) (*model.SocialEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetSocialEncounterById")
+	defer span.End()
+
+	objectID, err := primitive.ObjectIDFromHex(socialEncounterID)
+	if err != nil {
+		return nil, ErrEncounterNotFound
+	}
+
+	filter := bson.M{"_id": objectID, "deletedAt": notDeleted}
+
+	var encounter model.SocialEncounter
+	err = r.database().Collection("socialEncounters").FindOne(ctx, filter).Decode(&encounter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEncounterNotFound
+		}
+		return nil, err
+	}
+
+	return &encounter, nil
+}
+
+func (r *MongoEncounterRepository) GetSocialEncounterByEncounterId(ctx context.Context, encounterID string) (*model.SocialEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetSocialEncounterByEncounterId")
+	defer span.End()
+
+	filter := bson.M{"encounterId": encounterID, "deletedAt": notDeleted}
+
+	var encounter model.SocialEncounter
+	err := r.database().Collection("socialEncounters").FindOne(ctx, filter).Decode(&encounter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEncounterNotFound
+		}
+		return nil, err
+	}
+
+	return &encounter, nil
+}
+
+func (r *MongoEncounterRepository) GetHiddenLocationEncounterByEncounterId(ctx context.Context, encounterID string) (*model.HiddenLocationEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetHiddenLocationEncounterByEncounterId")
+	defer span.End()
+
+	filter := bson.M{"encounterId": encounterID, "deletedAt": notDeleted}
+
+	var encounter model.HiddenLocationEncounter
+	err := r.database().Collection("hiddenLocationEncounters").FindOne(ctx, filter).Decode(&encounter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEncounterNotFound
+		}
+		return nil, err
+	}
+
+	return &encounter, nil
+}
+
+// CheckInTourist adds the tourist to the social encounter and returns the updated document.
+func (r *MongoEncounterRepository) CheckInTourist(ctx context.Context, socialEncounterID primitive.ObjectID, touristID int) (*model.SocialEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.CheckInTourist")
+	defer span.End()
+
+	update := bson.M{"$addToSet": bson.M{"touristIDs": touristID}}
+	return r.updateSocialTourists(ctx, socialEncounterID, update)
+}
+
+// CheckOutTourists removes the tourists from the social encounter and returns the updated document.
+func (r *MongoEncounterRepository) CheckOutTourists(ctx context.Context, socialEncounterID primitive.ObjectID, touristIDs ...int) (*model.SocialEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.CheckOutTourists")
+	defer span.End()
+
+	update := bson.M{"$pull": bson.M{"touristIDs": bson.M{"$in": touristIDs}}}
+	return r.updateSocialTourists(ctx, socialEncounterID, update)
+}
+
+func (r *MongoEncounterRepository) updateSocialTourists(ctx context.Context, socialEncounterID primitive.ObjectID, update bson.M) (*model.SocialEncounter, error) {
+	collection := r.database().Collection("socialEncounters")
+
+	// $addToSet i $pull ne rade nad null vrednoscu, koju imaju susreti kreirani bez turista
+	nullFilter := bson.M{"_id": socialEncounterID, "touristIDs": nil, "deletedAt": notDeleted}
+	_, err := collection.UpdateOne(ctx, nullFilter, bson.M{"$set": bson.M{"touristIDs": bson.A{}}})
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"_id": socialEncounterID, "deletedAt": notDeleted}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var encounter model.SocialEncounter
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&encounter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEncounterNotFound
+		}
+		return nil, err
+	}
+
+	return &encounter, nil
+}
+
+// UpdateStatus sets the status only if the encounter is still in the expected status,
+// so concurrent transitions cannot overwrite each other. It reports whether the update happened.
+func (r *MongoEncounterRepository) UpdateStatus(ctx context.Context, encounterID primitive.ObjectID, expectedStatus string, status string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.UpdateStatus")
+	defer span.End()
+
+	filter := bson.M{"_id": encounterID, "status": expectedStatus, "deletedAt": notDeleted}
+	update := bson.M{"$set": bson.M{"status": status}}
+
+	result, err := r.database().Collection("encounters").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// Update replaces the editable fields of the encounter and returns it as stored.
+// The approval is written only when it is set, which is how an edit sends a
+// rejected encounter back for approval.
+func (repo *MongoEncounterRepository) Update(ctx context.Context, encounter *model.Encounter, expectedStatus string) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.Update")
+	defer span.End()
+
+	encounter.Location = model.NewGeoPoint(encounter.Latitude, encounter.Longitude)
+	fields, err := updateFields(encounter, "authorId")
+	if err != nil {
+		return nil, err
+	}
+
+	var updated model.Encounter
+	if err := repo.updateEncounterAndRead(ctx, encounter.ID, expectedStatus, bson.M{"$set": fields}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (repo *MongoEncounterRepository) UpdateHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter) (*model.HiddenLocationEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.UpdateHiddenLocationEncounter")
+	defer span.End()
+
+	fields, err := updateFields(encounter)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated model.HiddenLocationEncounter
+	if err := repo.updateAndRead(ctx, "hiddenLocationEncounters", encounter.ID, bson.M{"$set": fields}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// UpdateSocialEncounter leaves the checked in tourists alone, they change only
+// through CheckInTourist and CheckOutTourists.
+func (repo *MongoEncounterRepository) UpdateSocialEncounter(ctx context.Context, encounter *model.SocialEncounter) (*model.SocialEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.UpdateSocialEncounter")
+	defer span.End()
+
+	fields, err := updateFields(encounter, "touristIDs")
+	if err != nil {
+		return nil, err
+	}
+
+	var updated model.SocialEncounter
+	if err := repo.updateAndRead(ctx, "socialEncounters", encounter.ID, bson.M{"$set": fields}, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (repo *MongoEncounterRepository) PatchEncounter(ctx context.Context, encounter *model.Encounter, fields model.PatchedFields, expectedStatus string) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.PatchEncounter")
+	defer span.End()
+
+	if fields.Touches("latitude", "longitude") {
+		encounter.Location = model.NewGeoPoint(encounter.Latitude, encounter.Longitude)
+		fields.Set = append(fields.Set, "location")
+	}
+	update, err := patchUpdate(encounter, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated model.Encounter
+	if err := repo.updateEncounterAndRead(ctx, encounter.ID, expectedStatus, update, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (repo *MongoEncounterRepository) PatchHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter, fields model.PatchedFields) (*model.HiddenLocationEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.PatchHiddenLocationEncounter")
+	defer span.End()
+
+	update, err := patchUpdate(encounter, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated model.HiddenLocationEncounter
+	if err := repo.updateAndRead(ctx, "hiddenLocationEncounters", encounter.ID, update, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (repo *MongoEncounterRepository) PatchSocialEncounter(ctx context.Context, encounter *model.SocialEncounter, fields model.PatchedFields) (*model.SocialEncounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.PatchSocialEncounter")
+	defer span.End()
+
+	update, err := patchUpdate(encounter, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated model.SocialEncounter
+	if err := repo.updateAndRead(ctx, "socialEncounters", encounter.ID, update, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// updateAndRead applies the update to the document with the id that is not in the trash
+// and decodes the document as it is after the update into result.
+func (repo *MongoEncounterRepository) updateAndRead(ctx context.Context, collection string, id primitive.ObjectID, update bson.M, result interface{}) error {
+	filter := bson.M{"_id": id, "deletedAt": notDeleted}
+	return repo.findOneAndUpdate(ctx, collection, filter, update, result)
+}
+
+// updateEncounterAndRead is updateAndRead for an encounter that must still be in
+// expectedStatus, unless expectedStatus is empty.
+func (repo *MongoEncounterRepository) updateEncounterAndRead(ctx context.Context, id primitive.ObjectID, expectedStatus string, update bson.M, result interface{}) error {
+	if expectedStatus == "" {
+		return repo.updateAndRead(ctx, "encounters", id, update, result)
+	}
+
+	filter := bson.M{"_id": id, "status": expectedStatus, "deletedAt": notDeleted}
+	err := repo.findOneAndUpdate(ctx, "encounters", filter, update, result)
+	if !errors.Is(err, ErrEncounterNotFound) {
+		return err
+	}
+	// Susret postoji, ali mu je neko u medjuvremenu promenio status
+	if _, err := repo.GetEncounterById(ctx, id.Hex()); err != nil {
+		return err
+	}
+	return ErrStatusChanged
+}
+
+func (repo *MongoEncounterRepository) findOneAndUpdate(ctx context.Context, collection string, filter bson.M, update bson.M, result interface{}) error {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err := repo.database().Collection(collection).FindOneAndUpdate(ctx, filter, update, opts).Decode(result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrEncounterNotFound
+	}
+	return err
+}
+
+// SoftDeleteEncounter moves the encounter and its social and hidden location parts to the trash.
+func (r *MongoEncounterRepository) SoftDeleteEncounter(ctx context.Context, baseEncounterID string, deletedBy int) error {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.SoftDeleteEncounter")
+	defer span.End()
+
+	objectID, err := primitive.ObjectIDFromHex(baseEncounterID)
+	if err != nil {
+		return ErrEncounterNotFound
+	}
+
+	deletedAt := time.Now().UTC()
+	trash := bson.M{"$set": bson.M{"deletedAt": deletedAt, "deletedBy": deletedBy}}
+	return r.moveTrash(ctx, objectID, baseEncounterID, notDeleted, trash)
+}
+
+// RestoreEncounter takes the encounter and its parts out of the trash.
+func (r *MongoEncounterRepository) RestoreEncounter(ctx context.Context, baseEncounterID string) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.RestoreEncounter")
+	defer span.End()
+
+	objectID, err := primitive.ObjectIDFromHex(baseEncounterID)
+	if err != nil {
+		return nil, ErrEncounterNotFound
+	}
+
+	restore := bson.M{"$unset": bson.M{"deletedAt": "", "deletedBy": ""}}
+	err = r.moveTrash(ctx, objectID, baseEncounterID, bson.M{"$exists": true}, restore)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetEncounterById(ctx, baseEncounterID)
+}
+
+// moveTrash applies the update to the encounter, if its deletedAt matches, and to its parts
+// in one transaction.
+func (r *MongoEncounterRepository) moveTrash(ctx context.Context, objectID primitive.ObjectID, baseEncounterID string, deletedAt bson.M, update bson.M) error {
+	database := r.database()
+
+	return r.inTransaction(ctx, func(sessionCtx mongo.SessionContext) error {
+		result, err := database.Collection("encounters").UpdateOne(sessionCtx, bson.M{"_id": objectID, "deletedAt": deletedAt}, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrEncounterNotFound
+		}
+
+		subtypeFilter := bson.M{"encounterId": baseEncounterID, "deletedAt": deletedAt}
+		if _, err := database.Collection("socialEncounters").UpdateMany(sessionCtx, subtypeFilter, update); err != nil {
+			return err
+		}
+		if _, err := database.Collection("hiddenLocationEncounters").UpdateMany(sessionCtx, subtypeFilter, update); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (r *MongoEncounterRepository) GetDeletedEncounters(ctx context.Context) ([]*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetDeletedEncounters")
+	defer span.End()
+
+	return r.findEncounters(ctx, bson.M{"deletedAt": bson.M{"$exists": true}})
+}
+
+// GetEncounterIdsDeletedBefore returns the ids of encounters moved to the trash before the given time.
+func (r *MongoEncounterRepository) GetEncounterIdsDeletedBefore(ctx context.Context, before time.Time) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.GetEncounterIdsDeletedBefore")
+	defer span.End()
+
+	encounters, err := r.findEncounters(ctx, bson.M{"deletedAt": bson.M{"$lt": before}})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(encounters))
+	for _, encounter := range encounters {
+		ids = append(ids, encounter.ID.Hex())
+	}
+	return ids, nil
+}
+
+// PurgeEncounter permanently removes the encounter, its social and hidden location parts
+// and the executions started for it in one transaction, if it is in the trash since
+// before deletedBefore. A restore running at the same time either commits first, so
+// the encounter no longer matches, or waits for the purge.
+func (r *MongoEncounterRepository) PurgeEncounter(ctx context.Context, baseEncounterID string, deletedBefore time.Time) (*model.EncounterDeletion, error) {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.PurgeEncounter")
+	defer span.End()
+
+	objectID, err := primitive.ObjectIDFromHex(baseEncounterID)
+	if err != nil {
+		return nil, ErrEncounterNotFound
+	}
+
+	database := r.database()
+
+	var deletion *model.EncounterDeletion
+	err = r.inTransaction(ctx, func(sessionCtx mongo.SessionContext) error {
+		// WithTransaction moze ponoviti funkciju, pa brojevi krecu od nule
+		deletion = &model.EncounterDeletion{}
+
+		result, err := database.Collection("encounters").DeleteOne(sessionCtx, bson.M{"_id": objectID, "deletedAt": bson.M{"$lt": deletedBefore}})
+		if err != nil {
+			return err
+		}
+		if result.DeletedCount == 0 {
+			return ErrEncounterNotFound
+		}
+		deletion.Encounters = result.DeletedCount
+
+		subtypeFilter := bson.M{"encounterId": baseEncounterID}
+		result, err = database.Collection("socialEncounters").DeleteMany(sessionCtx, subtypeFilter)
+		if err != nil {
+			return err
+		}
+		deletion.SocialEncounters = result.DeletedCount
+
+		result, err = database.Collection("hiddenLocationEncounters").DeleteMany(sessionCtx, subtypeFilter)
+		if err != nil {
+			return err
+		}
+		deletion.HiddenLocationEncounters = result.DeletedCount
+
+		result, err = database.Collection("encounterExecutions").DeleteMany(sessionCtx, bson.M{"encounterId": objectID})
+		if err != nil {
+			return err
+		}
+		deletion.Executions = result.DeletedCount
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletion, nil
+}
+
+/* ono od pre jer vise ne treba jer sam uradila brisanje povezanih social i location na laksi nacin odmah u brisanju
+func (r *MongoEncounterRepository) GetSocialEncounterId(baseEncounterID int) (int, error) {
+	var socialEncounterID int
+
+	result := r.DatabaseConnection.Model(&model.SocialEncounter{}).Select("id").Where("encounter_id = ?", baseEncounterID).First(&socialEncounterID)
+	if result.Error != nil {
+    if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return -1, nil
+		}
+		return 0, result.Error
+	}
+	return socialEncounterID, nil
+}
+
+func (r *MongoEncounterRepository) GetHiddenLocationEncounterId(baseEncounterID int) (int, error) {
+	var hiddenLocationEncounterID int
+
+	result := r.DatabaseConnection.Model(&model.HiddenLocationEncounter{}).Select("id").Where("encounter_id = ?", baseEncounterID).First(&hiddenLocationEncounterID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return -1, nil
+		}
+		return 0, result.Error
+	}
+
+	// Ako nema greške, vraćamo ID društvenog susreta
+	return hiddenLocationEncounterID, nil
+}
+
+func (r *MongoEncounterRepository) DeleteSocialEncounter(socialEncounterID int) error {
+	result := r.DatabaseConnection.Exec("DELETE FROM social_encounters WHERE id = ?", socialEncounterID)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (r *MongoEncounterRepository) DeleteHiddenLocationEncounter(hiddenLocationEncounterID int) error {
+
+	result := r.DatabaseConnection.Exec("DELETE FROM hidden_location_encounters WHERE id = ?", hiddenLocationEncounterID)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+*/
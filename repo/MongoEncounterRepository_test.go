@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// createValidatedCollection creates the collection with a validator, so the step of a
+// transaction that writes a document failing it fails.
+func createValidatedCollection(t *testing.T, encounters *MongoEncounterRepository, collection string, validator bson.M) {
+	t.Helper()
+	opts := options.CreateCollection().SetValidator(validator)
+	if err := encounters.database().CreateCollection(context.Background(), collection, opts); err != nil {
+		t.Fatalf("CreateCollection(%s): %v", collection, err)
+	}
+}
+
+func TestMongoEncounterRepository_CreateEncounterWithDetailsRollsBack(t *testing.T) {
+	ctx := context.Background()
+	encounters := newMongoRepositories(t).encounters.(*MongoEncounterRepository)
+	createValidatedCollection(t, encounters, "hiddenLocationEncounters", bson.M{"neverValid": bson.M{"$exists": true}})
+
+	_, err := encounters.CreateEncounterWithDetails(ctx, &model.EncounterDetails{
+		Encounter:               model.Encounter{Name: "Spot", Status: model.Active.String(), Type: model.Location.String()},
+		HiddenLocationEncounter: &model.HiddenLocationEncounter{ImageURL: "https://example.com/spot.jpg", DistanceTreshold: 20},
+	})
+	if err == nil {
+		t.Fatal("CreateEncounterWithDetails succeeded although the part was rejected")
+	}
+
+	count, err := encounters.database().Collection("encounters").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("CountDocuments: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("%d encounters left behind by the failed create, want none", count)
+	}
+}
+
+func TestMongoEncounterRepository_SoftDeleteRollsBack(t *testing.T) {
+	ctx := context.Background()
+	encounters := newMongoRepositories(t).encounters.(*MongoEncounterRepository)
+	// Deo se moze upisati, ali ne i premestiti u kantu, pa pada poslednji korak
+	createValidatedCollection(t, encounters, "hiddenLocationEncounters", bson.M{"deletedAt": bson.M{"$exists": false}})
+	details, err := encounters.CreateEncounterWithDetails(ctx, &model.EncounterDetails{
+		Encounter:               model.Encounter{Name: "Spot", Status: model.Active.String(), Type: model.Location.String()},
+		HiddenLocationEncounter: &model.HiddenLocationEncounter{ImageURL: "https://example.com/spot.jpg", DistanceTreshold: 20},
+	})
+	if err != nil {
+		t.Fatalf("CreateEncounterWithDetails: %v", err)
+	}
+
+	if err := encounters.SoftDeleteEncounter(ctx, details.ID.Hex(), 3); err == nil {
+		t.Fatal("SoftDeleteEncounter succeeded although the part could not be moved")
+	}
+	if _, err := encounters.GetEncounterById(ctx, details.ID.Hex()); err != nil {
+		t.Errorf("GetEncounterById after the failed delete: %v, want the encounter out of the trash", err)
+	}
+}
+
+func TestMongoEncounterRepository_PurgeEncounterRollsBack(t *testing.T) {
+	ctx := context.Background()
+	encounters := newMongoRepositories(t).encounters.(*MongoEncounterRepository)
+	details, err := encounters.CreateEncounterWithDetails(ctx, &model.EncounterDetails{
+		Encounter:       model.Encounter{Name: "Square", Status: model.Active.String(), Type: model.Social.String()},
+		SocialEncounter: &model.SocialEncounter{TouristsRequiredForCompletion: 2, DistanceTreshold: 50},
+	})
+	if err != nil {
+		t.Fatalf("CreateEncounterWithDetails: %v", err)
+	}
+	id := details.ID.Hex()
+	if err := encounters.SoftDeleteEncounter(ctx, id, 3); err != nil {
+		t.Fatalf("SoftDeleteEncounter: %v", err)
+	}
+
+	// Iz pogleda se ne moze brisati, pa pada korak posle brisanja delova
+	database := encounters.database()
+	if err := database.Collection("encounterExecutions").Drop(ctx); err != nil {
+		t.Fatalf("Drop: %v", err)
+	}
+	if err := database.CreateView(ctx, "encounterExecutions", "encounters", mongo.Pipeline{}); err != nil {
+		t.Fatalf("CreateView: %v", err)
+	}
+
+	if _, err := encounters.PurgeEncounter(ctx, id, time.Now().Add(time.Minute)); err == nil {
+		t.Fatal("PurgeEncounter succeeded although the executions could not be removed")
+	}
+	deleted, err := encounters.GetDeletedEncounters(ctx)
+	if err != nil {
+		t.Fatalf("GetDeletedEncounters: %v", err)
+	}
+	parts, err := database.Collection("socialEncounters").CountDocuments(ctx, bson.M{"encounterId": id})
+	if err != nil {
+		t.Fatalf("CountDocuments: %v", err)
+	}
+	if len(deleted) != 1 || parts != 1 {
+		t.Errorf("%d encounters and %d parts left in the trash, want the failed purge to keep both", len(deleted), parts)
+	}
+}
@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var _ XpLedgerRepository = (*MongoXpLedgerRepository)(nil)
+
+type MongoXpLedgerRepository struct {
+	DatabaseConnection *mongo.Client
+	// DatabaseName defaults to DefaultDatabaseName.
+	DatabaseName string
+}
+
+func (repo *MongoXpLedgerRepository) collection() *mongo.Collection {
+	return mongoDatabase(repo.DatabaseConnection, repo.DatabaseName).Collection("xpLedger")
+}
+
+// EnsureIndexes creates the unique indexes on executionId and on userId and encounterId
+// that keep an execution, or an encounter completed again after its execution was
+// deleted, from being awarded twice, and the index used to list a tourist's entries.
+func (repo *MongoXpLedgerRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "MongoXpLedgerRepository.EnsureIndexes")
+	defer span.End()
+
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "executionId", Value: 1}},
+			Options: options.Index().SetName(executionIdIndexName).SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "encounterId", Value: 1}},
+			Options: options.Index().SetName(userIdEncounterIdIndexName).SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "awardedAt", Value: 1}},
+			Options: options.Index().SetName(userIdAwardedAtIndexName),
+		},
+	}
+	_, err := repo.collection().Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// Append records the entry and reports whether it was added. An entry for an
+// execution, or for an encounter the user has already been awarded, is ignored.
+func (repo *MongoXpLedgerRepository) Append(ctx context.Context, entry *model.XpEntry) (bool, error) {
+	ctx, span := tracer.Start(ctx, "MongoXpLedgerRepository.Append")
+	defer span.End()
+
+	entry.ID = primitive.NewObjectID()
+
+	_, err := repo.collection().InsertOne(ctx, entry)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (repo *MongoXpLedgerRepository) FindByUserId(ctx context.Context, userID int) ([]*model.XpEntry, error) {
+	ctx, span := tracer.Start(ctx, "MongoXpLedgerRepository.FindByUserId")
+	defer span.End()
+
+	filter := bson.M{"userId": userID}
+	opts := options.Find().SetSort(bson.D{{Key: "awardedAt", Value: 1}})
+
+	cursor, err := repo.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*model.XpEntry{}
+	for cursor.Next(ctx) {
+		var entry model.XpEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, nil
+}
@@ -1,29 +1,14 @@
 package repo
 
 import (
+	"context"
 	"database-example/model"
-
-	"gorm.io/gorm"
+	"errors"
 )
 
-type StudentRepository struct {
-	DatabaseConnection *gorm.DB
-}
-
-func (repo *StudentRepository) FindById(id string) (model.Student, error) {
-	student := model.Student{}
-	dbResult := repo.DatabaseConnection.First(&student, "id = ?", id)
-	if dbResult != nil {
-		return student, dbResult.Error
-	}
-	return student, nil
-}
+var ErrStudentNotFound = errors.New("student not found")
 
-func (repo *StudentRepository) CreateStudent(student *model.Student) error {
-	dbResult := repo.DatabaseConnection.Create(student)
-	if dbResult.Error != nil {
-		return dbResult.Error
-	}
-	println("Rows affected: ", dbResult.RowsAffected)
-	return nil
+type StudentRepository interface {
+	FindById(ctx context.Context, id string) (model.Student, error)
+	CreateStudent(ctx context.Context, student *model.Student) error
 }
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// GormStudentRepository needs PostgreSQL, so only the in-memory implementation is tested.
+func TestInMemoryStudentRepository(t *testing.T) {
+	ctx := context.Background()
+	students := &InMemoryStudentRepository{Database: NewInMemoryDatabase()}
+
+	student := &model.Student{Name: "Ana", Major: "Software Engineering"}
+	if err := students.CreateStudent(ctx, student); err != nil {
+		t.Fatalf("CreateStudent: %v", err)
+	}
+	if student.ID == uuid.Nil {
+		t.Fatal("created student has no id")
+	}
+
+	found, err := students.FindById(ctx, student.ID.String())
+	if err != nil {
+		t.Fatalf("FindById: %v", err)
+	}
+	if found != *student {
+		t.Errorf("FindById = %+v, want %+v", found, *student)
+	}
+
+	for _, id := range []string{uuid.NewString(), "not-an-id"} {
+		if _, err := students.FindById(ctx, id); !errors.Is(err, ErrStudentNotFound) {
+			t.Errorf("FindById(%q) error = %v, want ErrStudentNotFound", id, err)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+)
+
+type XpLedgerRepository interface {
+	// Append records the entry and reports whether it was added. An entry for an
+	// execution, or for an encounter the user has already been awarded, is ignored.
+	Append(ctx context.Context, entry *model.XpEntry) (bool, error)
+	// FindByUserId returns the user's entries, oldest first.
+	FindByUserId(ctx context.Context, userID int) ([]*model.XpEntry, error)
+}
@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestXpLedgerRepository(t *testing.T) {
+	ctx := context.Background()
+	forEachImplementation(t, func(t *testing.T, r repositories) {
+		entries, err := r.xpLedger.FindByUserId(ctx, 5)
+		if err != nil {
+			t.Fatalf("FindByUserId: %v", err)
+		}
+		if entries == nil || len(entries) != 0 {
+			t.Errorf("FindByUserId without entries = %#v, want an empty slice", entries)
+		}
+
+		awardedAt := time.Now().UTC().Truncate(time.Millisecond)
+		later := &model.XpEntry{UserID: 5, EncounterID: primitive.NewObjectID(), ExecutionID: primitive.NewObjectID(), XpPoints: 30, AwardedAt: awardedAt.Add(time.Minute)}
+		earlier := &model.XpEntry{UserID: 5, EncounterID: primitive.NewObjectID(), ExecutionID: primitive.NewObjectID(), XpPoints: 10, AwardedAt: awardedAt}
+		for _, entry := range []*model.XpEntry{later, earlier} {
+			added, err := r.xpLedger.Append(ctx, entry)
+			if err != nil || !added {
+				t.Fatalf("Append = %v, %v, want true", added, err)
+			}
+		}
+
+		duplicate := &model.XpEntry{UserID: 5, EncounterID: primitive.NewObjectID(), ExecutionID: later.ExecutionID, XpPoints: 30, AwardedAt: awardedAt}
+		added, err := r.xpLedger.Append(ctx, duplicate)
+		if err != nil || added {
+			t.Errorf("Append for an awarded execution = %v, %v, want false", added, err)
+		}
+		// Nova realizacija istog susreta, posle brisanja prethodne
+		repeated := &model.XpEntry{UserID: 5, EncounterID: later.EncounterID, ExecutionID: primitive.NewObjectID(), XpPoints: 30, AwardedAt: awardedAt}
+		added, err = r.xpLedger.Append(ctx, repeated)
+		if err != nil || added {
+			t.Errorf("Append for an awarded encounter = %v, %v, want false", added, err)
+		}
+
+		entries, err = r.xpLedger.FindByUserId(ctx, 5)
+		if err != nil {
+			t.Fatalf("FindByUserId: %v", err)
+		}
+		if len(entries) != 2 || entries[0].XpPoints != 10 || entries[1].XpPoints != 30 {
+			t.Errorf("entries = %+v, want 10 then 30 xp", entries)
+		}
+	})
+}
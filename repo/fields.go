@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// legacyFieldNames maps, per collection, the keys the driver derived from the Go field
+// names before the models got bson tags to the keys the models use now.
+var legacyFieldNames = map[string]bson.M{
+	"encounters": {
+		"xppoints":         "xpPoints",
+		"shouldbeapproved": "shouldBeApproved",
+	},
+	"socialEncounters": {
+		"encounterid":                   "encounterId",
+		"touristsrequiredforcompletion": "touristsRequiredForCompletion",
+		"distancetreshold":              "distanceTreshold",
+		"touristids":                    "touristIDs",
+	},
+	"hiddenLocationEncounters": {
+		"imageurl":         "imageURL",
+		"imagelatitude":    "imageLatitude",
+		"imagelongitude":   "imageLongitude",
+		"distancetreshold": "distanceTreshold",
+		"encounterid":      "encounterId",
+	},
+}
+
+// RenameLegacyFields moves documents stored under the legacy keys to the current ones.
+// Documents that are already migrated are not touched, so it is safe to run at every start.
+func (r *MongoEncounterRepository) RenameLegacyFields(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "MongoEncounterRepository.RenameLegacyFields")
+	defer span.End()
+
+	for collection, renames := range legacyFieldNames {
+		legacy := bson.A{}
+		for field := range renames {
+			legacy = append(legacy, bson.M{field: bson.M{"$exists": true}})
+		}
+		filter := bson.M{"$or": legacy}
+		_, err := r.database().Collection(collection).UpdateMany(ctx, filter, bson.M{"$rename": renames})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateFields returns the bson fields of the document for a $set, without the id,
+// the trash markers and the given fields that an update must not change.
+func updateFields(document interface{}, immutable ...string) (bson.M, error) {
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range append([]string{"_id", "deletedAt", "deletedBy"}, immutable...) {
+		delete(fields, field)
+	}
+	return fields, nil
+}
+
+// patchUpdate returns the $set and $unset of the patched fields, taking the values
+// from the merged document. A set field that encodes to nothing is unset.
+func patchUpdate(document interface{}, fields model.PatchedFields) (bson.M, error) {
+	values, err := updateFields(document)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
+	for _, field := range fields.Set {
+		if value, ok := values[field]; ok {
+			set[field] = value
+		} else {
+			unset[field] = ""
+		}
+	}
+	for _, field := range fields.Unset {
+		unset[field] = ""
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update, nil
+}
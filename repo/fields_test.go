@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoEncounterRepository_RenameLegacyFields(t *testing.T) {
+	ctx := context.Background()
+	encounters := newMongoRepositories(t).encounters.(*MongoEncounterRepository)
+
+	id := primitive.NewObjectID()
+	legacy := bson.M{"_id": id, "name": "Bridge", "xppoints": 40, "shouldbeapproved": true, "type": "Misc", "status": "Active"}
+	if _, err := encounters.database().Collection("encounters").InsertOne(ctx, legacy); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := encounters.RenameLegacyFields(ctx); err != nil {
+			t.Fatalf("RenameLegacyFields: %v", err)
+		}
+	}
+
+	encounter, err := encounters.GetEncounterById(ctx, id.Hex())
+	if err != nil {
+		t.Fatalf("GetEncounterById: %v", err)
+	}
+	if encounter.XpPoints != 40 || !encounter.ShouldBeApproved {
+		t.Errorf("got %+v, want the legacy fields renamed", encounter)
+	}
+}
+
+func TestUpdateFieldsLeavesOutImmutableFields(t *testing.T) {
+	fields, err := updateFields(bson.M{"_id": 1, "name": "Bridge", "authorId": 3, "deletedBy": 4}, "authorId")
+	if err != nil {
+		t.Fatalf("updateFields: %v", err)
+	}
+	if len(fields) != 1 || fields["name"] != "Bridge" {
+		t.Errorf("fields = %v, want only name", fields)
+	}
+}
+
+func TestPatchUpdateSetsOnlyPatchedFields(t *testing.T) {
+	encounter := &model.Encounter{ID: primitive.NewObjectID(), Name: "Bridge", XpPoints: 30}
+	update, err := patchUpdate(encounter, model.PatchedFields{Set: []string{"xpPoints", "approval"}, Unset: []string{"description"}})
+	if err != nil {
+		t.Fatalf("patchUpdate: %v", err)
+	}
+
+	want := bson.M{
+		"$set":   bson.M{"xpPoints": int32(30)},
+		"$unset": bson.M{"approval": "", "description": ""},
+	}
+	if !reflect.DeepEqual(update, want) {
+		t.Errorf("update = %v, want %v", update, want)
+	}
+}
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	locationIndexName          = "location_2dsphere"
+	executionIdIndexName       = "executionId_unique"
+	userIdAwardedAtIndexName   = "userId_awardedAt"
+	userIdEncounterIdIndexName = "userId_encounterId_unique"
+)
+
+// requiredIndexes are the indexes created at startup by MongoEncounterRepository.EnsureLocationIndex,
+// MongoEncounterExecutionRepository.EnsureIndexes and MongoXpLedgerRepository.EnsureIndexes.
+var requiredIndexes = []struct {
+	collection string
+	name       string
+}{
+	{"encounters", locationIndexName},
+	{"encounterExecutions", userIdEncounterIdIndexName},
+	{"xpLedger", executionIdIndexName},
+	{"xpLedger", userIdEncounterIdIndexName},
+	{"xpLedger", userIdAwardedAtIndexName},
+}
+
+// MissingMongoIndexes returns the required indexes, as collection.index, that do not exist.
+func MissingMongoIndexes(ctx context.Context, client *mongo.Client, databaseName string) ([]string, error) {
+	database := mongoDatabase(client, databaseName)
+	existing := map[string]map[string]bool{}
+	missing := []string{}
+
+	for _, index := range requiredIndexes {
+		if existing[index.collection] == nil {
+			names, err := indexNames(ctx, database.Collection(index.collection))
+			if err != nil {
+				return nil, err
+			}
+			existing[index.collection] = map[string]bool{}
+			for _, name := range names {
+				existing[index.collection][name] = true
+			}
+		}
+		if !existing[index.collection][index.name] {
+			missing = append(missing, index.collection+"."+index.name)
+		}
+	}
+	return missing, nil
+}
+
+func indexNames(ctx context.Context, collection *mongo.Collection) ([]string, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var names []string
+	for cursor.Next(ctx) {
+		var index bson.M
+		if err := cursor.Decode(&index); err != nil {
+			return nil, err
+		}
+		if name, ok := index["name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names, cursor.Err()
+}
@@ -0,0 +1,274 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"database-example/model"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+// MaxPageSize caps PageRequest.Limit.
+const MaxPageSize = 500
+
+// pageCursor is the position after which the next page starts: the sort value
+// and _id of the last returned document. Null is set when that document has no
+// sort value, because an omitted Value cannot be told apart from a null one.
+type pageCursor struct {
+	Value interface{} `json:"v,omitempty"`
+	Null  bool        `json:"null,omitempty"`
+	ID    string      `json:"id"`
+}
+
+func encodeCursor(cursor pageCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(token string) (pageCursor, primitive.ObjectID, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, primitive.NilObjectID, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, primitive.NilObjectID, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	id, err := primitive.ObjectIDFromHex(cursor.ID)
+	if err != nil {
+		return cursor, primitive.NilObjectID, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return cursor, id, nil
+}
+
+// resolveSortField validates the page and returns the bson key it is sorted by
+// together with the page size, which is zero when the page is not limited.
+func resolveSortField(page model.PageRequest, sortFields map[string]string) (string, int, error) {
+	sortBy := "id"
+	if page.SortBy != "" {
+		sortBy = page.SortBy
+	}
+	sortField, ok := sortFields[sortBy]
+	if !ok {
+		return "", 0, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, sortBy)
+	}
+	if page.Limit < 0 || page.Limit > MaxPageSize {
+		return "", 0, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	return sortField, page.Limit, nil
+}
+
+// findPage runs filter ordered by the sort field (one of sortFields, keyed by JSON name)
+// with _id as tie breaker, and returns the page after page.Cursor together with the
+// cursor of the following page, which is empty on the last page.
+func findPage[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, page model.PageRequest, sortFields map[string]string) ([]*T, string, error) {
+	sortField, limit, err := resolveSortField(page, sortFields)
+	if err != nil {
+		return nil, "", err
+	}
+
+	direction, compare := 1, "$gt"
+	if page.Descending {
+		direction, compare = -1, "$lt"
+	}
+
+	conditions := bson.A{filter}
+	if page.Cursor != "" {
+		cursor, lastID, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if sortField == "_id" {
+			conditions = append(conditions, bson.M{"_id": bson.M{compare: lastID}})
+		} else {
+			conditions = append(conditions, bson.M{"$or": afterCursor(sortField, cursor, lastID, page.Descending)})
+		}
+	}
+
+	sort := bson.D{{Key: sortField, Value: direction}}
+	if sortField != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: direction})
+	}
+	opts := options.Find().SetSort(sort)
+	if limit > 0 {
+		// Jedan vise da bismo znali da li postoji sledeca strana
+		opts.SetLimit(int64(limit) + 1)
+	}
+
+	result, err := collection.Find(ctx, bson.M{"$and": conditions}, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer result.Close(ctx)
+
+	var items []*T
+	var last bson.Raw
+	for result.Next(ctx) {
+		if limit > 0 && len(items) == limit {
+			next, err := nextCursor(last, sortField)
+			return items, next, err
+		}
+
+		var item T
+		if err := result.Decode(&item); err != nil {
+			return nil, "", err
+		}
+		items = append(items, &item)
+		last = append(last[:0], result.Current...)
+	}
+
+	return items, "", result.Err()
+}
+
+// afterCursor returns the conditions of which one matches every document that
+// comes after the cursor. Mongo sorts a missing or null value before any other,
+// while $gt and $lt never match it, so null is handled on its own.
+func afterCursor(sortField string, cursor pageCursor, lastID primitive.ObjectID, descending bool) bson.A {
+	compare := "$gt"
+	if descending {
+		compare = "$lt"
+	}
+	sameValue := bson.M{sortField: cursor.Value, "_id": bson.M{compare: lastID}}
+
+	switch {
+	case cursor.Null && descending:
+		return bson.A{sameValue}
+	case cursor.Null:
+		return bson.A{bson.M{sortField: bson.M{"$ne": nil}}, sameValue}
+	case descending:
+		return bson.A{bson.M{sortField: bson.M{compare: cursor.Value}}, bson.M{sortField: nil}, sameValue}
+	}
+	return bson.A{bson.M{sortField: bson.M{compare: cursor.Value}}, sameValue}
+}
+
+func nextCursor(last bson.Raw, sortField string) (string, error) {
+	cursor := pageCursor{ID: last.Lookup("_id").ObjectID().Hex()}
+	if sortField != "_id" {
+		value, err := last.LookupErr(sortField)
+		if err == nil {
+			if err := value.Unmarshal(&cursor.Value); err != nil {
+				return "", err
+			}
+		}
+		cursor.Null = cursor.Value == nil
+	}
+	return encodeCursor(cursor)
+}
+
+// pageInMemory applies the same ordering and cursor rules as findPage to a slice.
+// sortValue returns the value of the bson key the items are sorted by.
+func pageInMemory[T any](items []*T, page model.PageRequest, sortFields map[string]string, idOf func(*T) primitive.ObjectID, sortValue func(*T, string) interface{}) ([]*T, string, error) {
+	sortField, limit, err := resolveSortField(page, sortFields)
+	if err != nil {
+		return nil, "", err
+	}
+
+	direction := 1
+	if page.Descending {
+		direction = -1
+	}
+	compare := func(value interface{}, id primitive.ObjectID, item *T) int {
+		if sortField != "_id" {
+			if c := compareValues(value, sortValue(item, sortField)); c != 0 {
+				return c * direction
+			}
+		}
+		itemID := idOf(item)
+		return bytes.Compare(id[:], itemID[:]) * direction
+	}
+
+	sorted := append([]*T(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compare(sortValue(sorted[i], sortField), idOf(sorted[i]), sorted[j]) < 0
+	})
+
+	if page.Cursor != "" {
+		cursor, lastID, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start := len(sorted)
+		for i, item := range sorted {
+			if compare(cursor.Value, lastID, item) < 0 {
+				start = i
+				break
+			}
+		}
+		sorted = sorted[start:]
+	}
+
+	if limit == 0 || len(sorted) <= limit {
+		if len(sorted) == 0 {
+			return nil, "", nil
+		}
+		return sorted, "", nil
+	}
+
+	sorted = sorted[:limit]
+	last := sorted[len(sorted)-1]
+	cursor := pageCursor{ID: idOf(last).Hex()}
+	if sortField != "_id" {
+		cursor.Value = sortValue(last, sortField)
+		cursor.Null = cursor.Value == nil
+	}
+	next, err := encodeCursor(cursor)
+	return sorted, next, err
+}
+
+// compareValues orders nil, numbers and strings the way Mongo does for sort values
+// read back from a cursor, where every number has become a float64.
+func compareValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	aNumber, aIsNumber := toFloat(a)
+	bNumber, bIsNumber := toFloat(b)
+	switch {
+	case aIsNumber && bIsNumber:
+		switch {
+		case aNumber < bNumber:
+			return -1
+		case aNumber > bNumber:
+			return 1
+		}
+		return 0
+	case aIsNumber:
+		return -1
+	case bIsNumber:
+		return 1
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case float64:
+		return number, true
+	}
+	return 0, false
+}
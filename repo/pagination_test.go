@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"database-example/model"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var nullSortValueOrders = []struct {
+	name string
+	page model.PageRequest
+	want []string
+}{
+	{"ascending", model.PageRequest{SortBy: "xpPoints", Limit: 1}, []string{"Missing 1", "Null", "Missing 2", "Ten", "Twenty"}},
+	{"descending", model.PageRequest{SortBy: "xpPoints", Descending: true, Limit: 1}, []string{"Twenty", "Ten", "Missing 2", "Null", "Missing 1"}},
+}
+
+func TestMongoEncounterRepository_PagesPastMissingSortValues(t *testing.T) {
+	ctx := context.Background()
+	encounters := newMongoRepositories(t).encounters.(*MongoEncounterRepository)
+
+	documents := []interface{}{
+		bson.M{"_id": primitive.NewObjectID(), "name": "Missing 1", "shouldBeApproved": false},
+		bson.M{"_id": primitive.NewObjectID(), "name": "Null", "xpPoints": nil, "shouldBeApproved": false},
+		bson.M{"_id": primitive.NewObjectID(), "name": "Missing 2", "shouldBeApproved": false},
+		bson.M{"_id": primitive.NewObjectID(), "name": "Ten", "xpPoints": 10, "shouldBeApproved": false},
+		bson.M{"_id": primitive.NewObjectID(), "name": "Twenty", "xpPoints": 20, "shouldBeApproved": false},
+	}
+	if _, err := encounters.database().Collection("encounters").InsertMany(ctx, documents); err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+
+	for _, tt := range nullSortValueOrders {
+		var got []string
+		page := tt.page
+		for pages := 0; ; pages++ {
+			if pages > len(tt.want) {
+				t.Fatalf("%s: pagination does not end", tt.name)
+			}
+			found, next, err := encounters.GetAllEncounters(ctx, 0, model.EncounterFilter{}, page)
+			if err != nil {
+				t.Fatalf("%s: GetAllEncounters: %v", tt.name, err)
+			}
+			got = append(got, encounterNames(found)...)
+			if next == "" {
+				break
+			}
+			page.Cursor = next
+		}
+		if !equalNames(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPageInMemoryPagesPastNilSortValues(t *testing.T) {
+	type item struct {
+		id    primitive.ObjectID
+		name  string
+		value interface{}
+	}
+	var items []*item
+	for _, entry := range []struct {
+		name  string
+		value interface{}
+	}{{"Missing 1", nil}, {"Null", nil}, {"Missing 2", nil}, {"Ten", 10}, {"Twenty", 20}} {
+		items = append(items, &item{id: primitive.NewObjectID(), name: entry.name, value: entry.value})
+	}
+
+	for _, tt := range nullSortValueOrders {
+		var got []string
+		page := tt.page
+		for pages := 0; ; pages++ {
+			if pages > len(tt.want) {
+				t.Fatalf("%s: pagination does not end", tt.name)
+			}
+			found, next, err := pageInMemory(items, page, encounterSortFields, func(i *item) primitive.ObjectID {
+				return i.id
+			}, func(i *item, _ string) interface{} { return i.value })
+			if err != nil {
+				t.Fatalf("%s: pageInMemory: %v", tt.name, err)
+			}
+			for _, i := range found {
+				got = append(got, i.name)
+			}
+			if next == "" {
+				break
+			}
+			page.Cursor = next
+		}
+		if !equalNames(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoTestURI names the environment variable with the Mongo URI the contract tests
+// also run against. Transactions need a replica set, e.g. the single node from
+// docker-compose.yml: MONGO_TEST_URI=mongodb://localhost:27017/?replicaSet=rs0&directConnection=true
+const mongoTestURI = "MONGO_TEST_URI"
+
+type repositories struct {
+	encounters EncounterRepository
+	executions EncounterExecutionRepository
+	xpLedger   XpLedgerRepository
+}
+
+func newInMemoryRepositories(t *testing.T) repositories {
+	database := NewInMemoryDatabase()
+	return repositories{
+		encounters: &InMemoryEncounterRepository{Database: database},
+		executions: &InMemoryEncounterExecutionRepository{Database: database},
+		xpLedger:   &InMemoryXpLedgerRepository{Database: database},
+	}
+}
+
+// newMongoRepositories connects to a fresh database that is dropped when the test ends.
+func newMongoRepositories(t *testing.T) repositories {
+	uri := os.Getenv(mongoTestURI)
+	if uri == "" {
+		t.Skipf("%s is not set", mongoTestURI)
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to Mongo: %v", err)
+	}
+	databaseName := "encounters_test_" + primitive.NewObjectID().Hex()
+	t.Cleanup(func() {
+		client.Database(databaseName).Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	encounters := &MongoEncounterRepository{DatabaseConnection: client, DatabaseName: databaseName}
+	if err := encounters.EnsureLocationIndex(ctx); err != nil {
+		t.Fatalf("creating location index: %v", err)
+	}
+	executions := &MongoEncounterExecutionRepository{DatabaseConnection: client, DatabaseName: databaseName}
+	if err := executions.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("creating execution indexes: %v", err)
+	}
+	xpLedger := &MongoXpLedgerRepository{DatabaseConnection: client, DatabaseName: databaseName}
+	if err := xpLedger.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("creating xp ledger indexes: %v", err)
+	}
+
+	return repositories{
+		encounters: encounters,
+		executions: executions,
+		xpLedger:   xpLedger,
+	}
+}
+
+// forEachImplementation runs the contract test against every implementation, each
+// time with empty repositories.
+func forEachImplementation(t *testing.T, test func(t *testing.T, r repositories)) {
+	implementations := []struct {
+		name string
+		new  func(t *testing.T) repositories
+	}{
+		{"InMemory", newInMemoryRepositories},
+		{"Mongo", newMongoRepositories},
+	}
+
+	for _, implementation := range implementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			test(t, implementation.new(t))
+		})
+	}
+}
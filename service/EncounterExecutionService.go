@@ -1,17 +1,56 @@
 package service
 
 import (
+	"context"
+	"database-example/metrics"
 	"database-example/model"
 	"database-example/repo"
+	"errors"
+	"fmt"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
+var ErrExecutionNotFound = repo.ErrExecutionNotFound
+
+var ErrEncounterNotActive = newError(Conflict, "encounter is not active")
+
+var ErrExecutionAlreadyExists = newError(Conflict, "tourist has already activated this encounter")
+
+var ErrExecutionAlreadyCompleted = newError(Conflict, "encounter execution is already completed")
+
+var ErrNotHiddenLocationEncounter = newError(Conflict, "encounter is not a hidden location encounter")
+
+var ErrPositionCheckRequired = newError(Conflict, "hidden location and social encounters are completed from the tourist's position")
+
+// DefaultActivationRadius is used when EncounterExecutionService.ActivationRadius is not set.
+const DefaultActivationRadius = 100.0
+
+// TooFarFromEncounterError is returned when a tourist tries to activate or complete
+// an encounter from outside the allowed radius. Distances are in meters.
+type TooFarFromEncounterError struct {
+	Distance float64
+	Radius   float64
+}
+
+func (e *TooFarFromEncounterError) Error() string {
+	return fmt.Sprintf("tourist is %.0fm away from the encounter, must be within %.0fm", e.Distance, e.Radius)
+}
+
 type EncounterExecutionService struct {
-	EncounterExecutionRepo *repo.EncounterExecutionRepository
+	EncounterExecutionRepo repo.EncounterExecutionRepository
+	EncounterRepo          repo.EncounterRepository
+	XpLedgerRepo           repo.XpLedgerRepository
+	// ActivationRadius is how close, in meters, a tourist must be to activate an encounter.
+	ActivationRadius float64
 }
 
-func (service *EncounterExecutionService) GetExecutionByUser(userID int) (*model.EncounterExecution, error) {
-	encounter, err := service.EncounterExecutionRepo.FindByUserId(userID)
+func (service *EncounterExecutionService) GetExecutionByUser(ctx context.Context, userID int) (*model.EncounterExecution, error) {
+	ctx, span := tracer.Start(ctx, "EncounterExecutionService.GetExecutionByUser", trace.WithAttributes(userIDKey.Int(userID)))
+	defer span.End()
+
+	encounter, err := service.EncounterExecutionRepo.FindByUserId(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -21,51 +60,297 @@ func (service *EncounterExecutionService) GetExecutionByUser(userID int) (*model
 	return &encounter, nil
 }
 
-func (service *EncounterExecutionService) CompleteEncounter(userID int) (*model.EncounterExecution, error) {
-	encounter, err := service.EncounterExecutionRepo.FindByUserId(userID)
+func (service *EncounterExecutionService) CompleteEncounter(ctx context.Context, userID int) (*model.EncounterExecution, error) {
+	ctx, span := tracer.Start(ctx, "EncounterExecutionService.CompleteEncounter", trace.WithAttributes(userIDKey.Int(userID)))
+	defer span.End()
+
+	encounter, err := service.EncounterExecutionRepo.FindByUserId(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	encounter.CompletionTime = time.Now()
-	encounter.IsCompleted = true
+	if encounter.IsCompleted {
+		return nil, ErrExecutionAlreadyCompleted
+	}
 
-	// Neki update XP za korisnika
+	baseEncounter, err := service.EncounterRepo.GetEncounterById(ctx, encounter.EncounterID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	traceEncounter(ctx, baseEncounter)
+	if encounterType, _ := model.ParseEncounterType(baseEncounter.Type); encounterType == model.Location || encounterType == model.Social {
+		return nil, ErrPositionCheckRequired
+	}
 
-	err = service.EncounterExecutionRepo.Update(&encounter)
+	err = service.complete(ctx, &encounter, baseEncounter)
 	if err != nil {
 		return nil, err
 	}
 	return &encounter, nil
 }
 
-func (service *EncounterExecutionService) CreateEncounter(encounter *model.EncounterExecution) error {
-	err := service.EncounterExecutionRepo.Create(encounter)
+// CompleteHiddenLocationEncounter completes the tourist's execution once they are
+// within the hidden location's DistanceTreshold of the spot shown in the image.
+// Otherwise the execution is left running and the attempt reports the remaining distance.
+func (service *EncounterExecutionService) CompleteHiddenLocationEncounter(ctx context.Context, hiddenLocationEncounterID string, touristID int, latitude, longitude float64) (*model.CompletionAttempt, error) {
+	ctx, span := tracer.Start(ctx, "EncounterExecutionService.CompleteHiddenLocationEncounter", trace.WithAttributes(userIDKey.Int(touristID)))
+	defer span.End()
+
+	hiddenLocation, err := service.EncounterRepo.GetHiddenLocationEncounterById(ctx, hiddenLocationEncounterID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	encounter, err := service.EncounterRepo.GetEncounterById(ctx, hiddenLocation.EncounterID)
+	if err != nil {
+		return nil, err
+	}
+	traceEncounter(ctx, encounter)
+	if status, err := model.ParseEncounterStatus(encounter.Status); err != nil || status != model.Active {
+		return nil, ErrEncounterNotActive
+	}
+	if encounterType, err := model.ParseEncounterType(encounter.Type); err != nil || encounterType != model.Location {
+		return nil, ErrNotHiddenLocationEncounter
+	}
+
+	execution, err := service.EncounterExecutionRepo.FindByUserAndEncounter(ctx, touristID, encounter.ID)
+	if err != nil {
+		return nil, err
+	}
+	if execution.IsCompleted {
+		return nil, ErrExecutionAlreadyCompleted
+	}
+
+	distance := model.DistanceInMeters(latitude, longitude, hiddenLocation.ImageLatitude, hiddenLocation.ImageLongitude)
+	if distance > hiddenLocation.DistanceTreshold {
+		return &model.CompletionAttempt{
+			Distance:          distance,
+			RemainingDistance: distance - hiddenLocation.DistanceTreshold,
+			Execution:         &execution,
+		}, nil
+	}
+
+	err = service.complete(ctx, &execution, encounter)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CompletionAttempt{
+		Completed: true,
+		Distance:  distance,
+		Execution: &execution,
+	}, nil
+}
+
+// CheckIn adds a tourist standing within the social encounter's DistanceTreshold to it.
+// Once TouristsRequiredForCompletion tourists are checked in, the executions of all
+// of them are completed and they are checked out, so another group of tourists can
+// gather for it. Tourists who completed it cannot check in again.
+func (service *EncounterExecutionService) CheckIn(ctx context.Context, socialEncounterID string, touristID int, latitude, longitude float64) (*model.SocialCheckIn, error) {
+	ctx, span := tracer.Start(ctx, "EncounterExecutionService.CheckIn", trace.WithAttributes(userIDKey.Int(touristID)))
+	defer span.End()
+
+	social, err := service.EncounterRepo.GetSocialEncounterById(ctx, socialEncounterID)
+	if err != nil {
+		return nil, err
+	}
+	encounter, err := service.EncounterRepo.GetEncounterById(ctx, social.EncounterID)
+	if err != nil {
+		return nil, err
+	}
+	traceEncounter(ctx, encounter)
+	if status, err := model.ParseEncounterStatus(encounter.Status); err != nil || status != model.Active {
+		return nil, ErrEncounterNotActive
+	}
+
+	execution, err := service.EncounterExecutionRepo.FindByUserAndEncounter(ctx, touristID, encounter.ID)
+	if err != nil {
+		return nil, err
+	}
+	if execution.IsCompleted {
+		return nil, ErrExecutionAlreadyCompleted
+	}
+
+	distance := model.DistanceInMeters(latitude, longitude, encounter.Latitude, encounter.Longitude)
+	if distance > social.DistanceTreshold {
+		return nil, &TooFarFromEncounterError{Distance: distance, Radius: social.DistanceTreshold}
+	}
+
+	social, err = service.EncounterRepo.CheckInTourist(ctx, social.ID, touristID)
+	if err != nil {
+		return nil, err
+	}
+	checkIn := &model.SocialCheckIn{SocialEncounter: social}
+	if len(social.TouristIDs) < social.TouristsRequiredForCompletion {
+		return checkIn, nil
+	}
+
+	completedTouristIDs, err := service.completeForTourists(ctx, encounter, social.TouristIDs)
+	if err != nil {
+		return nil, err
+	}
+	social, err = service.EncounterRepo.CheckOutTourists(ctx, social.ID, social.TouristIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	checkIn.SocialEncounter = social
+	checkIn.Completed = true
+	checkIn.CompletedTouristIDs = completedTouristIDs
+	return checkIn, nil
+}
+
+func (service *EncounterExecutionService) CheckOut(ctx context.Context, socialEncounterID string, touristID int) (*model.SocialEncounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterExecutionService.CheckOut", trace.WithAttributes(userIDKey.Int(touristID)))
+	defer span.End()
+
+	social, err := service.EncounterRepo.GetSocialEncounterById(ctx, socialEncounterID)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(encounterIDKey.String(social.EncounterID), encounterTypeKey.String(model.Social.String()))
+	return service.EncounterRepo.CheckOutTourists(ctx, social.ID, touristID)
+}
+
+// completeForTourists completes the running executions of the encounter for the given
+// tourists and returns the ones that were completed.
+func (service *EncounterExecutionService) completeForTourists(ctx context.Context, encounter *model.Encounter, touristIDs []int) ([]int, error) {
+	var completed []int
+	for _, touristID := range touristIDs {
+		execution, err := service.EncounterExecutionRepo.FindByUserAndEncounter(ctx, touristID, encounter.ID)
+		if errors.Is(err, ErrExecutionNotFound) {
+			continue
+		}
+		if err != nil {
+			return completed, err
+		}
+		if execution.IsCompleted {
+			continue
+		}
+
+		if err := service.complete(ctx, &execution, encounter); err != nil {
+			return completed, err
+		}
+		completed = append(completed, touristID)
+	}
+	return completed, nil
 }
 
-func (service *EncounterExecutionService) UpdateEncounter(id int, encounter *model.EncounterExecution) error {
-	encounter.ID = id
-	err := service.EncounterExecutionRepo.Update(encounter)
+// complete awards the encounter's XP and marks the execution completed. The XP entry is
+// written first: the ledger ignores a second award for the same execution, so a retry
+// after a failed update cannot award twice.
+func (service *EncounterExecutionService) complete(ctx context.Context, execution *model.EncounterExecution, encounter *model.Encounter) error {
+	execution.CompletionTime = time.Now()
+	execution.IsCompleted = true
+
+	awarded, err := service.XpLedgerRepo.Append(ctx, &model.XpEntry{
+		UserID:      execution.UserID,
+		EncounterID: execution.EncounterID,
+		ExecutionID: execution.ID,
+		XpPoints:    encounter.XpPoints,
+		AwardedAt:   execution.CompletionTime,
+	})
 	if err != nil {
 		return err
 	}
+
+	if awarded {
+		metrics.XpAwarded.Add(float64(encounter.XpPoints))
+	}
+
+	err = service.EncounterExecutionRepo.Update(ctx, execution)
+	if err != nil {
+		return err
+	}
+
+	metrics.ExecutionsCompleted.WithLabelValues(encounterTypeLabel(encounter.Type)).Inc()
 	return nil
 }
 
-func (service *EncounterExecutionService) DeleteEncounter(id int) error {
-	err := service.EncounterExecutionRepo.Delete(id)
+func (service *EncounterExecutionService) GetTouristXp(ctx context.Context, userID int) (*model.TouristXp, error) {
+	ctx, span := tracer.Start(ctx, "EncounterExecutionService.GetTouristXp", trace.WithAttributes(userIDKey.Int(userID)))
+	defer span.End()
+
+	entries, err := service.XpLedgerRepo.FindByUserId(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	touristXp := &model.TouristXp{UserID: userID, Entries: entries}
+	for _, entry := range entries {
+		touristXp.TotalXp += entry.XpPoints
+	}
+	return touristXp, nil
+}
+
+// Activate starts an execution of an active encounter for a tourist standing
+// within the activation radius of it.
+func (service *EncounterExecutionService) Activate(ctx context.Context, encounterID string, touristID int, latitude, longitude float64) (*model.EncounterExecution, error) {
+	ctx, span := tracer.Start(ctx, "EncounterExecutionService.Activate", trace.WithAttributes(
+		encounterIDKey.String(encounterID),
+		userIDKey.Int(touristID),
+	))
+	defer span.End()
+
+	encounter, err := service.EncounterRepo.GetEncounterById(ctx, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	traceEncounter(ctx, encounter)
+
+	status, err := model.ParseEncounterStatus(encounter.Status)
+	if err != nil || status != model.Active {
+		return nil, ErrEncounterNotActive
+	}
+
+	radius := service.ActivationRadius
+	if radius <= 0 {
+		radius = DefaultActivationRadius
+	}
+	distance := model.DistanceInMeters(latitude, longitude, encounter.Latitude, encounter.Longitude)
+	if distance > radius {
+		return nil, &TooFarFromEncounterError{Distance: distance, Radius: radius}
+	}
+
+	_, err = service.EncounterExecutionRepo.FindByUserAndEncounter(ctx, touristID, encounter.ID)
+	if err == nil {
+		return nil, ErrExecutionAlreadyExists
+	}
+	if !errors.Is(err, ErrExecutionNotFound) {
+		return nil, err
+	}
+
+	execution := &model.EncounterExecution{
+		UserID:      touristID,
+		EncounterID: encounter.ID,
+	}
+	err = service.EncounterExecutionRepo.Create(ctx, execution)
+	if errors.Is(err, repo.ErrExecutionExists) {
+		// Istovremena aktivacija je prosla proveru iznad, jedinstveni indeks je zaustavlja
+		return nil, ErrExecutionAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ExecutionsActivated.WithLabelValues(encounterTypeLabel(encounter.Type)).Inc()
+	return execution, nil
+}
+
+func (service *EncounterExecutionService) DeleteEncounter(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "EncounterExecutionService.DeleteEncounter")
+	defer span.End()
+
+	err := service.EncounterExecutionRepo.Delete(ctx, id)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (service *EncounterExecutionService) GetAllEncounters() ([]*model.EncounterExecution, error) {
-	encounters, err := service.EncounterExecutionRepo.GetAll()
+func (service *EncounterExecutionService) GetAllEncounters(ctx context.Context) ([]*model.EncounterExecution, error) {
+	ctx, span := tracer.Start(ctx, "EncounterExecutionService.GetAllEncounters")
+	defer span.End()
+
+	encounters, err := service.EncounterExecutionRepo.GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
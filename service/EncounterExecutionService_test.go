@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"database-example/model"
+	"database-example/repo"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newExecutionService(database *repo.InMemoryDatabase) *EncounterExecutionService {
+	return &EncounterExecutionService{
+		EncounterExecutionRepo: &repo.InMemoryEncounterExecutionRepository{Database: database},
+		EncounterRepo:          &repo.InMemoryEncounterRepository{Database: database},
+		XpLedgerRepo:           &repo.InMemoryXpLedgerRepository{Database: database},
+	}
+}
+
+func TestEncounterExecutionService_Activate(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		status    model.EncounterStatus
+		latitude  float64
+		wantErr   error
+		wantFar   bool
+		activated bool
+	}{
+		{"at the encounter", model.Active, 45, nil, false, true},
+		{"within the radius", model.Active, 45.0005, nil, false, true},
+		{"outside the radius", model.Active, 45.01, nil, true, false},
+		{"draft encounter", model.Draft, 45, ErrEncounterNotActive, false, false},
+	}
+
+	for _, tt := range tests {
+		database := repo.NewInMemoryDatabase()
+		service := newExecutionService(database)
+		encounter, err := service.EncounterRepo.CreateEncounter(ctx, &model.Encounter{
+			Name: tt.name, Status: tt.status.String(), Type: model.Misc.String(), Latitude: 45, Longitude: 19,
+		})
+		if err != nil {
+			t.Fatalf("CreateEncounter: %v", err)
+		}
+
+		execution, err := service.Activate(ctx, encounter.ID.Hex(), 5, tt.latitude, 19)
+		var tooFar *TooFarFromEncounterError
+		switch {
+		case tt.wantFar:
+			if !errors.As(err, &tooFar) || tooFar.Radius != DefaultActivationRadius {
+				t.Errorf("%s: Activate error = %v, want TooFarFromEncounterError", tt.name, err)
+			}
+		case tt.wantErr != nil:
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: Activate error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: Activate: %v", tt.name, err)
+		}
+		if tt.activated && (execution == nil || execution.EncounterID != encounter.ID) {
+			t.Errorf("%s: execution = %+v", tt.name, execution)
+		}
+		if !tt.activated {
+			continue
+		}
+
+		if _, err := service.Activate(ctx, encounter.ID.Hex(), 5, tt.latitude, 19); !errors.Is(err, ErrExecutionAlreadyExists) {
+			t.Errorf("%s: second Activate error = %v, want ErrExecutionAlreadyExists", tt.name, err)
+		}
+	}
+}
+
+func TestEncounterExecutionService_CompleteAwardsXpOnce(t *testing.T) {
+	ctx := context.Background()
+	service := newExecutionService(repo.NewInMemoryDatabase())
+	encounter, err := service.EncounterRepo.CreateEncounter(ctx, &model.Encounter{
+		Name: "Bridge", XpPoints: 25, Status: model.Active.String(), Type: model.Misc.String(), Latitude: 45, Longitude: 19,
+	})
+	if err != nil {
+		t.Fatalf("CreateEncounter: %v", err)
+	}
+	if _, err := service.Activate(ctx, encounter.ID.Hex(), 5, 45, 19); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+
+	execution, err := service.CompleteEncounter(ctx, 5)
+	if err != nil {
+		t.Fatalf("CompleteEncounter: %v", err)
+	}
+	if !execution.IsCompleted {
+		t.Error("execution is not completed")
+	}
+	if _, err := service.CompleteEncounter(ctx, 5); !errors.Is(err, ErrExecutionAlreadyCompleted) {
+		t.Errorf("second CompleteEncounter error = %v, want ErrExecutionAlreadyCompleted", err)
+	}
+
+	touristXp, err := service.GetTouristXp(ctx, 5)
+	if err != nil {
+		t.Fatalf("GetTouristXp: %v", err)
+	}
+	if touristXp.TotalXp != 25 || len(touristXp.Entries) != 1 {
+		t.Errorf("touristXp = %+v, want one entry of 25 xp", touristXp)
+	}
+}
+
+func TestEncounterExecutionService_CompleteAgainAfterDeleteAwardsNoXp(t *testing.T) {
+	ctx := context.Background()
+	service := newExecutionService(repo.NewInMemoryDatabase())
+	encounter, err := service.EncounterRepo.CreateEncounter(ctx, &model.Encounter{
+		Name: "Bridge", XpPoints: 25, Status: model.Active.String(), Type: model.Misc.String(), Latitude: 45, Longitude: 19,
+	})
+	if err != nil {
+		t.Fatalf("CreateEncounter: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		execution, err := service.Activate(ctx, encounter.ID.Hex(), 5, 45, 19)
+		if err != nil {
+			t.Fatalf("Activate %d: %v", i+1, err)
+		}
+		if _, err := service.CompleteEncounter(ctx, 5); err != nil {
+			t.Fatalf("CompleteEncounter %d: %v", i+1, err)
+		}
+		if err := service.DeleteEncounter(ctx, execution.ID.Hex()); err != nil {
+			t.Fatalf("DeleteEncounter %d: %v", i+1, err)
+		}
+	}
+
+	touristXp, err := service.GetTouristXp(ctx, 5)
+	if err != nil {
+		t.Fatalf("GetTouristXp: %v", err)
+	}
+	if touristXp.TotalXp != 25 || len(touristXp.Entries) != 1 {
+		t.Errorf("touristXp = %+v, want one entry of 25 xp", touristXp)
+	}
+}
+
+func TestEncounterExecutionService_SocialCheckIn(t *testing.T) {
+	ctx := context.Background()
+	service := newExecutionService(repo.NewInMemoryDatabase())
+	details, err := service.EncounterRepo.CreateEncounterWithDetails(ctx, &model.EncounterDetails{
+		Encounter:       model.Encounter{Name: "Square", XpPoints: 10, Status: model.Active.String(), Type: model.Social.String(), Latitude: 45, Longitude: 19},
+		SocialEncounter: &model.SocialEncounter{TouristsRequiredForCompletion: 2, DistanceTreshold: 50},
+	})
+	if err != nil {
+		t.Fatalf("CreateEncounterWithDetails: %v", err)
+	}
+	socialID := details.SocialEncounter.ID.Hex()
+	for _, touristID := range []int{1, 2} {
+		if _, err := service.Activate(ctx, details.ID.Hex(), touristID, 45, 19); err != nil {
+			t.Fatalf("Activate(%d): %v", touristID, err)
+		}
+	}
+
+	var tooFar *TooFarFromEncounterError
+	if _, err := service.CheckIn(ctx, socialID, 1, 45.01, 19); !errors.As(err, &tooFar) {
+		t.Errorf("CheckIn from afar error = %v, want TooFarFromEncounterError", err)
+	}
+
+	checkIn, err := service.CheckIn(ctx, socialID, 1, 45, 19)
+	if err != nil {
+		t.Fatalf("CheckIn(1): %v", err)
+	}
+	if checkIn.Completed {
+		t.Error("completed with one of two tourists")
+	}
+
+	checkIn, err = service.CheckIn(ctx, socialID, 2, 45, 19)
+	if err != nil {
+		t.Fatalf("CheckIn(2): %v", err)
+	}
+	if !checkIn.Completed || len(checkIn.CompletedTouristIDs) != 2 || len(checkIn.SocialEncounter.TouristIDs) != 0 {
+		t.Errorf("checkIn = %+v, want both completed and checked out", checkIn)
+	}
+}
+
+func TestEncounterExecutionService_CompleteHiddenLocationEncounter(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name          string
+		encounterType model.EncounterType
+		archive       bool
+		latitude      float64
+		wantErr       error
+		wantCompleted bool
+	}{
+		{"at the spot", model.Location, false, 45.001, nil, true},
+		{"away from the spot", model.Location, false, 45, nil, false},
+		{"archived encounter", model.Location, true, 45.001, ErrEncounterNotActive, false},
+		{"misc encounter", model.Misc, false, 45.001, ErrNotHiddenLocationEncounter, false},
+	}
+
+	for _, tt := range tests {
+		service := newExecutionService(repo.NewInMemoryDatabase())
+		details, err := service.EncounterRepo.CreateEncounterWithDetails(ctx, &model.EncounterDetails{
+			Encounter: model.Encounter{Name: tt.name, XpPoints: 10, Status: model.Active.String(), Type: tt.encounterType.String(), Latitude: 45, Longitude: 19},
+			HiddenLocationEncounter: &model.HiddenLocationEncounter{
+				ImageURL: "https://example.com/spot.jpg", ImageLatitude: 45.001, ImageLongitude: 19, DistanceTreshold: 20,
+			},
+		})
+		if err != nil {
+			t.Fatalf("CreateEncounterWithDetails: %v", err)
+		}
+		if _, err := service.Activate(ctx, details.ID.Hex(), 5, 45, 19); err != nil {
+			t.Fatalf("%s: Activate: %v", tt.name, err)
+		}
+		if tt.archive {
+			if _, err := service.EncounterRepo.UpdateStatus(ctx, details.ID, model.Active.String(), model.Archived.String()); err != nil {
+				t.Fatalf("UpdateStatus: %v", err)
+			}
+		}
+
+		attempt, err := service.CompleteHiddenLocationEncounter(ctx, details.HiddenLocationEncounter.ID.Hex(), 5, tt.latitude, 19)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: CompleteHiddenLocationEncounter error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: CompleteHiddenLocationEncounter: %v", tt.name, err)
+		}
+		if attempt.Completed != tt.wantCompleted {
+			t.Errorf("%s: attempt = %+v, want completed %v", tt.name, attempt, tt.wantCompleted)
+		}
+	}
+}
+
+func TestEncounterExecutionService_ActivateTracesEncounterAndTourist(t *testing.T) {
+	// Globalni tracer paketa se vezuje za prvi postavljeni provider, pa ga postavlja samo ovaj test
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx := context.Background()
+	service := newExecutionService(repo.NewInMemoryDatabase())
+	encounter, err := service.EncounterRepo.CreateEncounter(ctx, &model.Encounter{
+		Name: "Traced", Status: model.Active.String(), Type: model.Misc.String(), Latitude: 45, Longitude: 19,
+	})
+	if err != nil {
+		t.Fatalf("CreateEncounter: %v", err)
+	}
+	if _, err := service.Activate(ctx, encounter.ID.Hex(), 5, 45, 19); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		encounterIDKey:   attribute.StringValue(encounter.ID.Hex()),
+		encounterTypeKey: attribute.StringValue(model.Misc.String()),
+		userIDKey:        attribute.IntValue(5),
+	}
+	for _, span := range recorder.Ended() {
+		if span.Name() != "EncounterExecutionService.Activate" {
+			continue
+		}
+		got := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			got[kv.Key] = kv.Value
+		}
+		for key, value := range want {
+			if got[key] != value {
+				t.Errorf("%s = %v, want %v", key, got[key].Emit(), value.Emit())
+			}
+		}
+		return
+	}
+	t.Fatal("no EncounterExecutionService.Activate span was recorded")
+}
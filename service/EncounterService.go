@@ -1,55 +1,436 @@
 package service
 
 import (
+	"context"
+	"database-example/metrics"
 	"database-example/model"
 	"database-example/repo"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var ErrEncounterNotFound = errors.New("encounter not found")
+var tracer = otel.Tracer("database-example/service")
+
+// Span attributes of the encounter and the user an operation is about.
+const (
+	encounterIDKey   = attribute.Key("encounter.id")
+	encounterTypeKey = attribute.Key("encounter.type")
+	userIDKey        = attribute.Key("user.id")
+)
+
+// traceEncounter adds the id and type of the encounter to the current span.
+func traceEncounter(ctx context.Context, encounter *model.Encounter) {
+	trace.SpanFromContext(ctx).SetAttributes(
+		encounterIDKey.String(encounter.ID.Hex()),
+		encounterTypeKey.String(encounterTypeLabel(encounter.Type)),
+	)
+}
+
+var ErrEncounterNotFound = repo.ErrEncounterNotFound
+
+var ErrInvalidQuery = repo.ErrInvalidQuery
+
+var ErrInvalidEncounterStatus = newError(Validation, "invalid encounter status", FieldError{Field: "status", Message: "must be Draft, Active or Archived"})
+
+var ErrEncounterNotApproved = newError(Conflict, "encounter is waiting for approval or was rejected")
+
+var ErrEncounterNotPending = newError(Conflict, "encounter is not waiting for approval")
+
+var ErrOwnEncounterReview = newError(Forbidden, "authors cannot review their own encounters")
+
+var ErrRejectionReasonRequired = newError(Validation, "rejection reason is required", FieldError{Field: "reason", Message: "is required"})
+
+var ErrEncounterSubtypeMismatch = newError(Validation, "encounter type requires exactly its own subtype", FieldError{Field: "type", Message: "must match the single socialEncounter or hiddenLocationEncounter given"})
+
+// InvalidStatusTransitionError is returned when an encounter cannot move from its
+// current status to the requested one.
+type InvalidStatusTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("encounter cannot change status from %s to %s", e.From, e.To)
+}
+
+var encounterStatusTransitions = map[model.EncounterStatus][]model.EncounterStatus{
+	model.Draft:    {model.Active, model.Archived},
+	model.Active:   {model.Archived},
+	model.Archived: {model.Active},
+}
+
+func canChangeStatus(from, to model.EncounterStatus) bool {
+	for _, allowed := range encounterStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
 
 type EncounterService struct {
-	EncounterRepo *repo.EncounterRepository
+	EncounterRepo repo.EncounterRepository
 }
 
-func (service *EncounterService) Create(encounter *model.Encounter) (*model.Encounter, error) {
-	createdEncounter, err := service.EncounterRepo.CreateEncounter(encounter)
+func (service *EncounterService) Create(ctx context.Context, encounter *model.Encounter) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.Create")
+	defer span.End()
+
+	if err := validatePayload(encounter); err != nil {
+		return nil, err
+	}
+	if err := prepareNewEncounter(encounter); err != nil {
+		return nil, err
+	}
+
+	createdEncounter, err := service.EncounterRepo.CreateEncounter(ctx, encounter)
 	if err != nil {
 		return nil, err
 	}
+	traceEncounter(ctx, createdEncounter)
+	span.SetAttributes(userIDKey.Int(createdEncounter.AuthorID))
 
+	metrics.EncountersCreated.WithLabelValues(encounterTypeLabel(createdEncounter.Type)).Inc()
 	return createdEncounter, nil
 }
 
-func (service *EncounterService) CreateSocialEncounter(encounter *model.SocialEncounter) error {
-	err := service.EncounterRepo.CreateSocialEncounter(encounter)
+// CreateWithDetails creates the encounter together with the subtype required by its Type.
+func (service *EncounterService) CreateWithDetails(ctx context.Context, details *model.EncounterDetails) (*model.EncounterDetails, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.CreateWithDetails")
+	defer span.End()
+
+	encounterType, err := model.ParseEncounterType(details.Type)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrEncounterSubtypeMismatch, details.Type)
+	}
+	hasSocial := details.SocialEncounter != nil
+	hasHiddenLocation := details.HiddenLocationEncounter != nil
+	if hasSocial != (encounterType == model.Social) || hasHiddenLocation != (encounterType == model.Location) {
+		return nil, fmt.Errorf("%w: %s", ErrEncounterSubtypeMismatch, encounterType)
+	}
+	if err := validatePayload(details); err != nil {
+		return nil, err
+	}
+	if err := prepareNewEncounter(&details.Encounter); err != nil {
+		return nil, err
+	}
+
+	created, err := service.EncounterRepo.CreateEncounterWithDetails(ctx, details)
+	if err != nil {
+		return nil, err
+	}
+	traceEncounter(ctx, &created.Encounter)
+	span.SetAttributes(userIDKey.Int(created.AuthorID))
+
+	metrics.EncountersCreated.WithLabelValues(encounterType.String()).Inc()
+	return created, nil
+}
+
+// encounterTypeLabel keeps unknown types stored by older clients out of the metric labels.
+func encounterTypeLabel(value string) string {
+	encounterType, err := model.ParseEncounterType(value)
+	if err != nil {
+		return "Unknown"
+	}
+	return encounterType.String()
+}
+
+// prepareNewEncounter defaults and normalizes the status and starts the approval
+// of encounters proposed by tourists.
+func prepareNewEncounter(encounter *model.Encounter) error {
+	if encounter.Status == "" {
+		encounter.Status = model.Draft.String()
+	}
+	status, err := model.ParseEncounterStatus(encounter.Status)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidEncounterStatus, encounter.Status)
+	}
+	encounter.Status = status.String()
+	encounter.Approval = nil
+	if encounter.ShouldBeApproved {
+		// Predlog turiste ostaje u pripremi dok ga administrator ne odobri
+		encounter.Status = model.Draft.String()
+		encounter.Approval = &model.EncounterApproval{Decision: model.ApprovalPending}
+	}
+	return nil
+}
+
+func (service *EncounterService) CreateSocialEncounter(ctx context.Context, encounter *model.SocialEncounter) error {
+	ctx, span := tracer.Start(ctx, "EncounterService.CreateSocialEncounter")
+	defer span.End()
+
+	if err := service.validatePart(ctx, encounter, encounter.EncounterID, model.Social); err != nil {
+		return err
+	}
+	err := service.EncounterRepo.CreateSocialEncounter(ctx, encounter)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (service *EncounterService) CreateHiddenLocationEncounter(encounter *model.HiddenLocationEncounter) error {
-	err := service.EncounterRepo.CreateHiddenLocationEncounter(encounter)
+func (service *EncounterService) CreateHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter) error {
+	ctx, span := tracer.Start(ctx, "EncounterService.CreateHiddenLocationEncounter")
+	defer span.End()
+
+	if err := service.validatePart(ctx, encounter, encounter.EncounterID, model.Location); err != nil {
+		return err
+	}
+	err := service.EncounterRepo.CreateHiddenLocationEncounter(ctx, encounter)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *EncounterService) GetAllEncounters() ([]*model.Encounter, error) {
+// GetAllEncounters returns a page of encounters matching the filter and the cursor of
+// the next page, which is empty on the last one.
+func (s *EncounterService) GetAllEncounters(ctx context.Context, viewerID int, filter model.EncounterFilter, page model.PageRequest) ([]*model.Encounter, string, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.GetAllEncounters")
+	defer span.End()
+
+	if filter.Status != "" {
+		if _, err := model.ParseEncounterStatus(filter.Status); err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+		}
+	}
+	if filter.Type != "" {
+		if _, err := model.ParseEncounterType(filter.Type); err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+		}
+	}
+	if filter.MinXp != nil && filter.MaxXp != nil && *filter.MinXp > *filter.MaxXp {
+		return nil, "", fmt.Errorf("%w: minXp is greater than maxXp", ErrInvalidQuery)
+	}
+	if box := filter.BoundingBox; box != nil && box.MinLatitude > box.MaxLatitude {
+		return nil, "", fmt.Errorf("%w: bbox south edge is north of its north edge", ErrInvalidQuery)
+	}
+
 	// Poziv baze podataka ili nekog drugog skladišta podataka da dobijemo sve susrete
-	encounters, err := s.EncounterRepo.GetAllEncounters()
+	encounters, next, err := s.EncounterRepo.GetAllEncounters(ctx, viewerID, filter, page)
 	if err != nil {
 		// Ukoliko dođe do greške, vraćamo praznu listu i grešku
+		return nil, "", err
+	}
+
+	return encounters, next, nil
+}
+
+// GetEncounterById returns the encounter with its social or hidden location part,
+// depending on its Type. Encounters waiting for approval are only found by their author.
+func (s *EncounterService) GetEncounterById(ctx context.Context, encounterID string, viewerID int) (*model.EncounterDetails, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.GetEncounterById", trace.WithAttributes(
+		encounterIDKey.String(encounterID),
+		userIDKey.Int(viewerID),
+	))
+	defer span.End()
+
+	encounter, err := s.EncounterRepo.GetEncounterById(ctx, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	traceEncounter(ctx, encounter)
+	if !encounter.IsApproved() && encounter.AuthorID != viewerID {
+		return nil, ErrEncounterNotFound
+	}
+
+	details := &model.EncounterDetails{Encounter: *encounter}
+	encounterType, _ := model.ParseEncounterType(encounter.Type)
+	switch encounterType {
+	case model.Social:
+		details.SocialEncounter, err = s.EncounterRepo.GetSocialEncounterByEncounterId(ctx, encounterID)
+	case model.Location:
+		details.HiddenLocationEncounter, err = s.EncounterRepo.GetHiddenLocationEncounterByEncounterId(ctx, encounterID)
+	}
+	// Susret moze postojati pre nego sto mu je dodat deo za tip
+	if err != nil && !errors.Is(err, ErrEncounterNotFound) {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+func (s *EncounterService) GetNearbyEncounters(ctx context.Context, latitude, longitude, radius float64, viewerID int) ([]*model.NearbyEncounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.GetNearbyEncounters")
+	defer span.End()
+
+	encounters, err := s.EncounterRepo.GetNearbyEncounters(ctx, latitude, longitude, radius, viewerID)
+	if err != nil {
 		return nil, err
 	}
 
 	return encounters, nil
 }
 
-func (s *EncounterService) GetAllHiddenLocationEncounters() ([]*model.HiddenLocationEncounter, error) {
-	encounters, err := s.EncounterRepo.GetAllHiddenLocationEncounters()
+func (s *EncounterService) GetAllHiddenLocationEncounters(ctx context.Context, viewerID int, page model.PageRequest) ([]*model.HiddenLocationEncounter, string, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.GetAllHiddenLocationEncounters")
+	defer span.End()
+
+	encounters, next, err := s.EncounterRepo.GetAllHiddenLocationEncounters(ctx, viewerID, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return encounters, next, nil
+}
+
+func (s *EncounterService) GetAllSocialEncounters(ctx context.Context, viewerID int, page model.PageRequest) ([]*model.SocialEncounter, string, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.GetAllSocialEncounters")
+	defer span.End()
+
+	encounters, next, err := s.EncounterRepo.GetAllSocialEncounters(ctx, viewerID, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return encounters, next, nil
+}
+
+// Update replaces the editable fields of the encounter and returns it as stored.
+func (s *EncounterService) Update(ctx context.Context, encounter *model.Encounter) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.Update", trace.WithAttributes(encounterIDKey.String(encounter.ID.Hex())))
+	defer span.End()
+
+	if err := validatePayload(encounter); err != nil {
+		return nil, err
+	}
+	current, err := s.EncounterRepo.GetEncounterById(ctx, encounter.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	traceEncounter(ctx, current)
+	if err := prepareChangedEncounter(current, encounter); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.EncounterRepo.Update(ctx, encounter, current.Status)
+	if errors.Is(err, repo.ErrStatusChanged) {
+		return nil, &InvalidStatusTransitionError{From: current.Status, To: encounter.Status}
+	}
+	return updated, err
+}
+
+// Patch applies a JSON merge patch to the encounter and writes only the fields the
+// patch names, so concurrent edits of other fields are kept.
+func (s *EncounterService) Patch(ctx context.Context, encounterID string, patch []byte) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.Patch", trace.WithAttributes(encounterIDKey.String(encounterID)))
+	defer span.End()
+
+	current, err := s.EncounterRepo.GetEncounterById(ctx, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	traceEncounter(ctx, current)
+
+	var merged model.Encounter
+	fields, err := mergePatch(current, patch, &merged, "_id", "authorId", "shouldBeApproved", "approval", "deletedAt", "deletedBy")
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePayload(&merged); err != nil {
+		return nil, err
+	}
+	if err := prepareChangedEncounter(current, &merged); err != nil {
+		return nil, err
+	}
+	if fields.IsEmpty() {
+		return current, nil
+	}
+	if merged.Approval != nil {
+		fields.Set = append(fields.Set, "approval")
+	}
+
+	// Status se menja samo ako ga u medjuvremenu niko drugi nije promenio
+	expectedStatus := ""
+	if fields.Touches("status") {
+		expectedStatus = current.Status
+	}
+	patched, err := s.EncounterRepo.PatchEncounter(ctx, &merged, fields, expectedStatus)
+	if errors.Is(err, repo.ErrStatusChanged) {
+		return nil, &InvalidStatusTransitionError{From: current.Status, To: merged.Status}
+	}
+	return patched, err
+}
+
+// prepareChangedEncounter checks the status change of an edited encounter and keeps
+// the fields an edit must not change. Editing a rejected proposal sends it back for
+// approval: Approval is then set to pending, otherwise it is cleared and not written.
+func prepareChangedEncounter(current, encounter *model.Encounter) error {
+	status, err := model.ParseEncounterStatus(encounter.Status)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidEncounterStatus, encounter.Status)
+	}
+	if err := checkStatusTransition(current.Status, status); err != nil {
+		return err
+	}
+	if status == model.Active && !current.IsApproved() {
+		return ErrEncounterNotApproved
+	}
+	encounter.Status = status.String()
+	// Odobravanje se ne moze zaobici izmenom susreta
+	encounter.ShouldBeApproved = current.ShouldBeApproved
+	encounter.Approval = nil
+	if current.ShouldBeApproved && current.Approval != nil && current.Approval.Decision == model.ApprovalRejected {
+		encounter.Approval = &model.EncounterApproval{Decision: model.ApprovalPending}
+	}
+	return nil
+}
+
+func (s *EncounterService) Activate(ctx context.Context, encounterID string) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.Activate", trace.WithAttributes(encounterIDKey.String(encounterID)))
+	defer span.End()
+
+	return s.changeStatus(ctx, encounterID, model.Active)
+}
+
+func (s *EncounterService) Archive(ctx context.Context, encounterID string) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.Archive", trace.WithAttributes(encounterIDKey.String(encounterID)))
+	defer span.End()
+
+	return s.changeStatus(ctx, encounterID, model.Archived)
+}
+
+func (s *EncounterService) changeStatus(ctx context.Context, encounterID string, status model.EncounterStatus) (*model.Encounter, error) {
+	encounter, err := s.EncounterRepo.GetEncounterById(ctx, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	traceEncounter(ctx, encounter)
+	if err := checkStatusTransition(encounter.Status, status); err != nil {
+		return nil, err
+	}
+	if status == model.Active && !encounter.IsApproved() {
+		return nil, ErrEncounterNotApproved
+	}
+	if encounter.Status == status.String() {
+		return encounter, nil
+	}
+
+	updated, err := s.EncounterRepo.UpdateStatus(ctx, encounter.ID, encounter.Status, status.String())
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		// Neko drugi je u medjuvremenu promenio status
+		return nil, &InvalidStatusTransitionError{From: encounter.Status, To: status.String()}
+	}
+
+	encounter.Status = status.String()
+	return encounter, nil
+}
+
+func (s *EncounterService) GetPendingEncounters(ctx context.Context) ([]*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.GetPendingEncounters")
+	defer span.End()
+
+	encounters, err := s.EncounterRepo.GetPendingEncounters(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -57,8 +438,11 @@ func (s *EncounterService) GetAllHiddenLocationEncounters() ([]*model.HiddenLoca
 	return encounters, nil
 }
 
-func (s *EncounterService) GetAllSocialEncounters() ([]*model.SocialEncounter, error) {
-	encounters, err := s.EncounterRepo.GetAllSocialEncounters()
+func (s *EncounterService) GetEncountersByAuthor(ctx context.Context, authorID int) ([]*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.GetEncountersByAuthor", trace.WithAttributes(userIDKey.Int(authorID)))
+	defer span.End()
+
+	encounters, err := s.EncounterRepo.GetEncountersByAuthor(ctx, authorID)
 	if err != nil {
 		return nil, err
 	}
@@ -66,56 +450,262 @@ func (s *EncounterService) GetAllSocialEncounters() ([]*model.SocialEncounter, e
 	return encounters, nil
 }
 
-func (s *EncounterService) Update(encounter *model.Encounter) error {
-	// Ažuriranje susreta u repozitorijumu
-	err := s.EncounterRepo.Update(encounter)
+// Approve publishes a pending encounter by making it active.
+func (s *EncounterService) Approve(ctx context.Context, encounterID string, reviewerID int) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.Approve", trace.WithAttributes(
+		encounterIDKey.String(encounterID),
+		userIDKey.Int(reviewerID),
+	))
+	defer span.End()
+
+	return s.review(ctx, encounterID, reviewerID, model.ApprovalApproved, "", model.Active)
+}
+
+// Reject returns a pending encounter to its author as a draft, together with the reason.
+func (s *EncounterService) Reject(ctx context.Context, encounterID string, reviewerID int, reason string) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.Reject", trace.WithAttributes(
+		encounterIDKey.String(encounterID),
+		userIDKey.Int(reviewerID),
+	))
+	defer span.End()
+
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrRejectionReasonRequired
+	}
+	return s.review(ctx, encounterID, reviewerID, model.ApprovalRejected, reason, model.Draft)
+}
+
+func (s *EncounterService) review(ctx context.Context, encounterID string, reviewerID int, decision model.ApprovalDecision, reason string, status model.EncounterStatus) (*model.Encounter, error) {
+	if reviewerID <= 0 {
+		return nil, InvalidField("reviewerId", "must be a positive integer")
+	}
+	encounter, err := s.EncounterRepo.GetEncounterById(ctx, encounterID)
 	if err != nil {
-		// Provera da li je susret pronađen
-		if errors.Is(err, ErrEncounterNotFound) {
-			return ErrEncounterNotFound
-		}
-		// Vraćanje drugih grešaka ako se nešto drugo dogodi
-		return err
+		return nil, err
+	}
+	traceEncounter(ctx, encounter)
+	if encounter.AuthorID == reviewerID {
+		return nil, ErrOwnEncounterReview
 	}
 
-	return nil
+	reviewedAt := time.Now().UTC()
+	approval := &model.EncounterApproval{
+		Decision:   decision,
+		ReviewerID: reviewerID,
+		Reason:     reason,
+		ReviewedAt: &reviewedAt,
+	}
+
+	updated, err := s.EncounterRepo.ReviewEncounter(ctx, encounter.ID, approval, status.String())
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrEncounterNotPending
+	}
+
+	encounter.Approval = approval
+	encounter.Status = status.String()
+	return encounter, nil
 }
 
-func (s *EncounterService) UpdateHiddenLocationEncounter(encounter *model.HiddenLocationEncounter) error {
+// checkStatusTransition allows keeping the current status or moving along encounterStatusTransitions.
+func checkStatusTransition(currentStatus string, status model.EncounterStatus) error {
+	current, err := model.ParseEncounterStatus(currentStatus)
+	if err != nil {
+		return &InvalidStatusTransitionError{From: currentStatus, To: status.String()}
+	}
+	if current == status || canChangeStatus(current, status) {
+		return nil
+	}
+	return &InvalidStatusTransitionError{From: current.String(), To: status.String()}
+}
+
+func (s *EncounterService) UpdateHiddenLocationEncounter(ctx context.Context, encounter *model.HiddenLocationEncounter) (*model.HiddenLocationEncounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.UpdateHiddenLocationEncounter", trace.WithAttributes(
+		encounterIDKey.String(encounter.EncounterID),
+		encounterTypeKey.String(model.Location.String()),
+	))
+	defer span.End()
+
+	if err := s.validatePart(ctx, encounter, encounter.EncounterID, model.Location); err != nil {
+		return nil, err
+	}
+	return s.EncounterRepo.UpdateHiddenLocationEncounter(ctx, encounter)
+}
+
+func (s *EncounterService) UpdateSocialEncounter(ctx context.Context, encounter *model.SocialEncounter) (*model.SocialEncounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.UpdateSocialEncounter", trace.WithAttributes(
+		encounterIDKey.String(encounter.EncounterID),
+		encounterTypeKey.String(model.Social.String()),
+	))
+	defer span.End()
+
+	if err := s.validatePart(ctx, encounter, encounter.EncounterID, model.Social); err != nil {
+		return nil, err
+	}
+	return s.EncounterRepo.UpdateSocialEncounter(ctx, encounter)
+}
 
-	err := s.EncounterRepo.UpdateHiddenLocationEncounter(encounter)
+// PatchHiddenLocationEncounter applies a JSON merge patch to the hidden location encounter.
+func (s *EncounterService) PatchHiddenLocationEncounter(ctx context.Context, hiddenLocationEncounterID string, patch []byte) (*model.HiddenLocationEncounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.PatchHiddenLocationEncounter", trace.WithAttributes(
+		encounterTypeKey.String(model.Location.String()),
+	))
+	defer span.End()
+
+	current, err := s.EncounterRepo.GetHiddenLocationEncounterById(ctx, hiddenLocationEncounterID)
 	if err != nil {
-		if errors.Is(err, ErrEncounterNotFound) {
-			return ErrEncounterNotFound
-		}
-		return err
+		return nil, err
 	}
+	span.SetAttributes(encounterIDKey.String(current.EncounterID))
 
-	return nil
+	var merged model.HiddenLocationEncounter
+	fields, err := mergePatch(current, patch, &merged, "_id", "deletedAt", "deletedBy")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validatePart(ctx, &merged, merged.EncounterID, model.Location); err != nil {
+		return nil, err
+	}
+	if fields.IsEmpty() {
+		return current, nil
+	}
+
+	return s.EncounterRepo.PatchHiddenLocationEncounter(ctx, &merged, fields)
 }
 
-func (s *EncounterService) UpdateSocialEncounter(encounter *model.SocialEncounter) error {
+// PatchSocialEncounter applies a JSON merge patch to the social encounter. The checked
+// in tourists change only through check-in and check-out.
+func (s *EncounterService) PatchSocialEncounter(ctx context.Context, socialEncounterID string, patch []byte) (*model.SocialEncounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.PatchSocialEncounter", trace.WithAttributes(
+		encounterTypeKey.String(model.Social.String()),
+	))
+	defer span.End()
 
-	err := s.EncounterRepo.UpdateSocialEncounter(encounter)
+	current, err := s.EncounterRepo.GetSocialEncounterById(ctx, socialEncounterID)
 	if err != nil {
-		if errors.Is(err, ErrEncounterNotFound) {
-			return ErrEncounterNotFound
-		}
-		return err
+		return nil, err
 	}
+	span.SetAttributes(encounterIDKey.String(current.EncounterID))
 
-	return nil
+	var merged model.SocialEncounter
+	fields, err := mergePatch(current, patch, &merged, "_id", "touristIDs", "deletedAt", "deletedBy")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validatePart(ctx, &merged, merged.EncounterID, model.Social); err != nil {
+		return nil, err
+	}
+	if fields.IsEmpty() {
+		return current, nil
+	}
+
+	return s.EncounterRepo.PatchSocialEncounter(ctx, &merged, fields)
 }
 
-func (s *EncounterService) DeleteEncounter(baseEncounterID string) error {
+// DeleteEncounter moves the encounter to the trash, from where it can be restored
+// until the purge job removes it.
+func (s *EncounterService) DeleteEncounter(ctx context.Context, baseEncounterID string, deletedBy int) error {
+
+	ctx, span := tracer.Start(ctx, "EncounterService.DeleteEncounter", trace.WithAttributes(
+		encounterIDKey.String(baseEncounterID),
+		userIDKey.Int(deletedBy),
+	))
+	defer span.End()
 
-	err := s.EncounterRepo.DeleteEncounter(baseEncounterID)
+	err := s.EncounterRepo.SoftDeleteEncounter(ctx, baseEncounterID, deletedBy)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func (s *EncounterService) RestoreEncounter(ctx context.Context, baseEncounterID string) (*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.RestoreEncounter", trace.WithAttributes(encounterIDKey.String(baseEncounterID)))
+	defer span.End()
+
+	return s.EncounterRepo.RestoreEncounter(ctx, baseEncounterID)
+}
+
+// PurgeEncounter permanently removes an encounter from the trash without waiting for
+// the purge job and returns how many documents of each kind were removed.
+func (s *EncounterService) PurgeEncounter(ctx context.Context, baseEncounterID string) (*model.EncounterDeletion, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.PurgeEncounter", trace.WithAttributes(encounterIDKey.String(baseEncounterID)))
+	defer span.End()
+
+	return s.EncounterRepo.PurgeEncounter(ctx, baseEncounterID, time.Now().UTC())
+}
+
+func (s *EncounterService) GetDeletedEncounters(ctx context.Context) ([]*model.Encounter, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.GetDeletedEncounters")
+	defer span.End()
+
+	encounters, err := s.EncounterRepo.GetDeletedEncounters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return encounters, nil
+}
+
+// PurgeDeletedEncounters permanently removes encounters that have been in the trash
+// longer than retention and returns what was removed.
+func (s *EncounterService) PurgeDeletedEncounters(ctx context.Context, retention time.Duration) (*model.EncounterDeletion, error) {
+	ctx, span := tracer.Start(ctx, "EncounterService.PurgeDeletedEncounters")
+	defer span.End()
+
+	cutoff := time.Now().UTC().Add(-retention)
+	ids, err := s.EncounterRepo.GetEncounterIdsDeletedBefore(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	purged := &model.EncounterDeletion{}
+	for _, id := range ids {
+		// Susret vracen iz korpe u medjuvremenu repozitorijum ne brise
+		deletion, err := s.EncounterRepo.PurgeEncounter(ctx, id, cutoff)
+		if errors.Is(err, ErrEncounterNotFound) {
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+		purged.Encounters += deletion.Encounters
+		purged.SocialEncounters += deletion.SocialEncounters
+		purged.HiddenLocationEncounters += deletion.HiddenLocationEncounters
+		purged.Executions += deletion.Executions
+	}
+	return purged, nil
+}
+
+// RunPurgeJob purges the trash every interval until ctx is done.
+func (s *EncounterService) RunPurgeJob(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeDeletedEncounters(ctx, retention)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to purge deleted encounters", "error", err)
+				continue
+			}
+			if purged.Encounters > 0 {
+				slog.InfoContext(ctx, "Purged deleted encounters",
+					"encounters", purged.Encounters,
+					"social_encounters", purged.SocialEncounters,
+					"hidden_location_encounters", purged.HiddenLocationEncounters,
+					"executions", purged.Executions,
+				)
+			}
+		}
+	}
+}
+
 /*
 
 
@@ -169,28 +759,4 @@ func (s *EncounterService) DeleteHiddenLocationEncounter(hiddenLocationEncounter
 	}
 	return nil
 }
-
-
-
-func (s *EncounterService) GetHiddenLocationEncounterByEncounterId(encounterId int) (*model.HiddenLocationEncounter, error) {
-	// Call the repository method to fetch the hidden location encounter by encounter ID
-	hiddenLocationEncounter, err := s.EncounterRepo.GetHiddenLocationEncounterByEncounterId(encounterId)
-	if err != nil {
-		// If there's an error, return nil and the error
-		return nil, err
-	}
-
-	return hiddenLocationEncounter, nil
-}
-
-// (s *EncounterService) - prijemnik funkcije, na kom tipu (objektu) je funkcija definisana
-// (encounterId int) - parametri funkcije
-// (*model.Encounter, error) - povratni tipovi funkcije
-func (s *EncounterService) GetEncounterById(encounterId int) (*model.Encounter, error) {
-	encounter, err := s.EncounterRepo.GetEncounterById(encounterId)
-	if err != nil {
-		return nil, err
-	}
-
-	return encounter, nil
-}*/
+*/
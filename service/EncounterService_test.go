@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"database-example/model"
+	"database-example/repo"
+	"errors"
+	"testing"
+)
+
+func newEncounterService() *EncounterService {
+	return &EncounterService{EncounterRepo: &repo.InMemoryEncounterRepository{Database: repo.NewInMemoryDatabase()}}
+}
+
+func TestEncounterService_StatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		from    model.EncounterStatus
+		to      model.EncounterStatus
+		allowed bool
+	}{
+		{model.Draft, model.Active, true},
+		{model.Draft, model.Archived, true},
+		{model.Active, model.Archived, true},
+		{model.Archived, model.Active, true},
+		{model.Active, model.Active, true},
+		{model.Active, model.Draft, false},
+		{model.Archived, model.Draft, false},
+	}
+
+	for _, tt := range tests {
+		service := newEncounterService()
+		encounter, err := service.Create(ctx, &model.Encounter{Name: "Bridge", Status: tt.from.String(), Type: model.Misc.String()})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		encounter.Status = tt.to.String()
+		_, err = service.Update(ctx, encounter)
+		var transitionErr *InvalidStatusTransitionError
+		if tt.allowed && err != nil {
+			t.Errorf("%s -> %s: Update error = %v", tt.from, tt.to, err)
+		}
+		if !tt.allowed && !errors.As(err, &transitionErr) {
+			t.Errorf("%s -> %s: Update error = %v, want InvalidStatusTransitionError", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestEncounterService_CreateNormalizesStatus(t *testing.T) {
+	ctx := context.Background()
+	service := newEncounterService()
+
+	encounter, err := service.Create(ctx, &model.Encounter{Name: "Bridge", Status: "active", Type: model.Misc.String()})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if encounter.Status != model.Active.String() {
+		t.Errorf("Status = %q, want %q", encounter.Status, model.Active)
+	}
+
+	_, err = service.Create(ctx, &model.Encounter{Name: "Bridge", Status: "Published", Type: model.Misc.String()})
+	if fields := FieldsOf(err); KindOf(err) != Validation || len(fields) != 1 || fields[0].Field != "status" {
+		t.Errorf("Create with unknown status error = %v, fields %+v, want an invalid status", err, fields)
+	}
+}
+
+func TestEncounterService_Approval(t *testing.T) {
+	ctx := context.Background()
+	service := newEncounterService()
+	proposal, err := service.Create(ctx, &model.Encounter{Name: "Proposal", Status: model.Active.String(), Type: model.Misc.String(), ShouldBeApproved: true, AuthorID: 7})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := proposal.ID.Hex()
+	if proposal.Status != model.Draft.String() || proposal.Approval == nil || proposal.Approval.Decision != model.ApprovalPending {
+		t.Fatalf("proposal = %+v, want a pending draft", proposal)
+	}
+
+	if _, err := service.GetEncounterById(ctx, id, 8); !errors.Is(err, ErrEncounterNotFound) {
+		t.Errorf("GetEncounterById by another tourist error = %v, want ErrEncounterNotFound", err)
+	}
+	if _, err := service.GetEncounterById(ctx, id, 7); err != nil {
+		t.Errorf("GetEncounterById by the author: %v", err)
+	}
+	if _, err := service.Activate(ctx, id); !errors.Is(err, ErrEncounterNotApproved) {
+		t.Errorf("Activate before approval error = %v, want ErrEncounterNotApproved", err)
+	}
+	if _, err := service.Reject(ctx, id, 1, " "); !errors.Is(err, ErrRejectionReasonRequired) {
+		t.Errorf("Reject without reason error = %v, want ErrRejectionReasonRequired", err)
+	}
+	if _, err := service.Approve(ctx, id, 0); KindOf(err) != Validation || len(FieldsOf(err)) != 1 || FieldsOf(err)[0].Field != "reviewerId" {
+		t.Errorf("Approve without reviewer error = %v, want an invalid reviewerId", err)
+	}
+	if _, err := service.Approve(ctx, id, 7); !errors.Is(err, ErrOwnEncounterReview) {
+		t.Errorf("Approve by the author error = %v, want ErrOwnEncounterReview", err)
+	}
+
+	approved, err := service.Approve(ctx, id, 1)
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if approved.Status != model.Active.String() || approved.Approval.ReviewerID != 1 {
+		t.Errorf("approved = %+v", approved)
+	}
+	if _, err := service.GetEncounterById(ctx, id, 8); err != nil {
+		t.Errorf("GetEncounterById after approval: %v", err)
+	}
+	if _, err := service.Reject(ctx, id, 1, "too late"); !errors.Is(err, ErrEncounterNotPending) {
+		t.Errorf("Reject after approval error = %v, want ErrEncounterNotPending", err)
+	}
+}
+
+func TestEncounterService_EditingRejectedProposalResubmitsIt(t *testing.T) {
+	ctx := context.Background()
+	service := newEncounterService()
+	proposal, err := service.Create(ctx, &model.Encounter{Name: "Proposal", Type: model.Misc.String(), ShouldBeApproved: true, AuthorID: 7})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := proposal.ID.Hex()
+
+	edits := []struct {
+		name string
+		edit func(name string) (*model.Encounter, error)
+	}{
+		{"Update", func(name string) (*model.Encounter, error) {
+			edited := *proposal
+			edited.Name = name
+			return service.Update(ctx, &edited)
+		}},
+		{"Patch", func(name string) (*model.Encounter, error) {
+			return service.Patch(ctx, id, []byte(`{"name": "`+name+`"}`))
+		}},
+	}
+	for _, tt := range edits {
+		if _, err := service.Reject(ctx, id, 1, "needs a better name"); err != nil {
+			t.Fatalf("%s: Reject: %v", tt.name, err)
+		}
+		edited, err := tt.edit("Better " + tt.name)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if edited.Approval == nil || edited.Approval.Decision != model.ApprovalPending {
+			t.Errorf("%s: approval = %+v, want the edited proposal pending again", tt.name, edited.Approval)
+		}
+	}
+
+	if _, err := service.Approve(ctx, id, 1); err != nil {
+		t.Fatalf("Approve of the edited proposal: %v", err)
+	}
+	edited, err := service.Patch(ctx, id, []byte(`{"name": "Approved"}`))
+	if err != nil {
+		t.Fatalf("Patch after approval: %v", err)
+	}
+	if edited.Approval == nil || edited.Approval.Decision != model.ApprovalApproved {
+		t.Errorf("approval = %+v, an edit must keep the approval", edited.Approval)
+	}
+}
+
+func TestEncounterService_CreateWithDetailsChecksSubtype(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		details model.EncounterDetails
+		wantErr bool
+	}{
+		{"social with social part", model.EncounterDetails{
+			Encounter:       model.Encounter{Name: "Square", Type: model.Social.String()},
+			SocialEncounter: &model.SocialEncounter{TouristsRequiredForCompletion: 2, DistanceTreshold: 50},
+		}, false},
+		{"misc without parts", model.EncounterDetails{Encounter: model.Encounter{Name: "Bridge", Type: model.Misc.String()}}, false},
+		{"social without social part", model.EncounterDetails{Encounter: model.Encounter{Type: model.Social.String()}}, true},
+		{"location with social part", model.EncounterDetails{
+			Encounter:       model.Encounter{Type: model.Location.String()},
+			SocialEncounter: &model.SocialEncounter{},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		_, err := newEncounterService().CreateWithDetails(ctx, &tt.details)
+		if tt.wantErr != errors.Is(err, ErrEncounterSubtypeMismatch) || (!tt.wantErr && err != nil) {
+			t.Errorf("%s: CreateWithDetails error = %v", tt.name, err)
+		}
+	}
+}
@@ -1,25 +1,32 @@
 package service
 
 import (
+	"context"
 	"database-example/model"
 	"database-example/repo"
 	"fmt"
 )
 
 type StudentService struct {
-	StudentRepo *repo.StudentRepository
+	StudentRepo repo.StudentRepository
 }
 
-func (service *StudentService) FindStudent(id string) (*model.Student, error) {
-	student, err := service.StudentRepo.FindById(id)
+func (service *StudentService) FindStudent(ctx context.Context, id string) (*model.Student, error) {
+	ctx, span := tracer.Start(ctx, "StudentService.FindStudent")
+	defer span.End()
+
+	student, err := service.StudentRepo.FindById(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf(fmt.Sprintf("menu item with id %s not found", id))
 	}
 	return &student, nil
 }
 
-func (service *StudentService) Create(student *model.Student) error {
-	err := service.StudentRepo.CreateStudent(student)
+func (service *StudentService) Create(ctx context.Context, student *model.Student) error {
+	ctx, span := tracer.Start(ctx, "StudentService.Create")
+	defer span.End()
+
+	err := service.StudentRepo.CreateStudent(ctx, student)
 	if err != nil {
 		return err
 	}
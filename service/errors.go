@@ -0,0 +1,78 @@
+package service
+
+import (
+	"database-example/repo"
+	"errors"
+)
+
+// Kind classifies domain errors by what went wrong, so that handlers can report
+// every error of a kind the same way without knowing the individual errors.
+type Kind int
+
+const (
+	// Internal errors are failures of the service or its database, not of the request.
+	Internal Kind = iota
+	NotFound
+	Validation
+	Conflict
+	Forbidden
+)
+
+// Error is a domain error of a Kind.
+type Error struct {
+	Kind    Kind
+	Message string
+	// Fields lists the invalid fields of a Validation error.
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// FieldError describes why a single field of a request is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func newError(kind Kind, message string, fields ...FieldError) *Error {
+	return &Error{Kind: kind, Message: message, Fields: fields}
+}
+
+// InvalidField returns a Validation error for a single field of a request.
+func InvalidField(field, message string) *Error {
+	return newError(Validation, field+" "+message, FieldError{Field: field, Message: message})
+}
+
+// KindOf returns the kind of err. Errors that are not domain errors are Internal.
+func KindOf(err error) Kind {
+	var domainErr *Error
+	var transitionErr *InvalidStatusTransitionError
+	var tooFarErr *TooFarFromEncounterError
+	switch {
+	case errors.As(err, &domainErr):
+		return domainErr.Kind
+	case errors.As(err, &transitionErr):
+		return Conflict
+	case errors.As(err, &tooFarErr):
+		return Forbidden
+	case errors.Is(err, repo.ErrEncounterNotFound),
+		errors.Is(err, repo.ErrExecutionNotFound),
+		errors.Is(err, repo.ErrStudentNotFound):
+		return NotFound
+	case errors.Is(err, repo.ErrInvalidQuery):
+		return Validation
+	default:
+		return Internal
+	}
+}
+
+// FieldsOf returns the invalid fields reported by err, if any.
+func FieldsOf(err error) []FieldError {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Fields
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"bytes"
+	"database-example/model"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// mergePatch applies the RFC 7386 JSON merge patch to current and decodes the result
+// into merged. It returns the top level fields the patch sets and removes, and
+// rejects patches that name fields merged does not have or touch one of the
+// immutable fields.
+func mergePatch(current interface{}, patch []byte, merged interface{}, immutable ...string) (model.PatchedFields, error) {
+	var fields model.PatchedFields
+
+	var changes map[string]interface{}
+	if err := decodeJSONValue(patch, &changes); err != nil || changes == nil {
+		return fields, newError(Validation, "merge patch must be a JSON object")
+	}
+
+	// Pogresno napisano polje bi se inace tiho obrisalo iz dokumenta
+	known := jsonFieldNames(reflect.TypeOf(merged))
+	var unknown []FieldError
+	for field := range changes {
+		if !known[field] {
+			unknown = append(unknown, FieldError{Field: field, Message: "is not a known field"})
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Slice(unknown, func(i, j int) bool { return unknown[i].Field < unknown[j].Field })
+		return fields, newError(Validation, "merge patch names unknown fields", unknown...)
+	}
+
+	var invalid []FieldError
+	for _, field := range immutable {
+		if _, ok := changes[field]; ok {
+			invalid = append(invalid, FieldError{Field: field, Message: "cannot be changed"})
+		}
+	}
+	if len(invalid) > 0 {
+		return fields, newError(Validation, "merge patch changes read-only fields", invalid...)
+	}
+
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return fields, err
+	}
+	var document interface{}
+	if err := decodeJSONValue(currentJSON, &document); err != nil {
+		return fields, err
+	}
+	mergedJSON, err := json.Marshal(mergeValue(document, changes))
+	if err != nil {
+		return fields, err
+	}
+	if err := json.Unmarshal(mergedJSON, merged); err != nil {
+		return fields, newError(Validation, "merge patch does not fit the document: "+err.Error())
+	}
+
+	for field, value := range changes {
+		if value == nil {
+			fields.Unset = append(fields.Unset, field)
+		} else {
+			fields.Set = append(fields.Set, field)
+		}
+	}
+	// Redosled polja ne zavisi od redosleda u mapi
+	sort.Strings(fields.Set)
+	sort.Strings(fields.Unset)
+	return fields, nil
+}
+
+// jsonFieldNames returns the names the struct (or pointer to struct) is encoded
+// with by encoding/json, including the fields of embedded structs.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	names := map[string]bool{}
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		switch {
+		case name == "-":
+			continue
+		case field.Anonymous && name == "":
+			for embedded := range jsonFieldNames(field.Type) {
+				names[embedded] = true
+			}
+			continue
+		case !field.IsExported():
+			continue
+		case name == "":
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// mergeValue is the MergePatch function of RFC 7386: objects are merged member by
+// member, null removes a member and any other value replaces the target.
+func mergeValue(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = mergeValue(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+// decodeJSONValue keeps numbers as json.Number, so integers survive the round trip exactly.
+func decodeJSONValue(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"database-example/model"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeValue(t *testing.T) {
+	// Primeri iz dodatka A RFC 7386
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		var target, patch, want interface{}
+		json.Unmarshal([]byte(tt.target), &target)
+		json.Unmarshal([]byte(tt.patch), &patch)
+		json.Unmarshal([]byte(tt.want), &want)
+		if got := mergeValue(target, patch); !reflect.DeepEqual(got, want) {
+			t.Errorf("merge %s with %s = %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestEncounterService_Patch(t *testing.T) {
+	ctx := context.Background()
+	service := newEncounterService()
+	encounter, err := service.Create(ctx, &model.Encounter{Name: "Bridge", Description: "Old bridge", XpPoints: 10, Type: model.Misc.String(), AuthorID: 3})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	id := encounter.ID.Hex()
+
+	patched, err := service.Patch(ctx, id, []byte(`{"xpPoints": 40, "description": null, "status": "active"}`))
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if patched.XpPoints != 40 || patched.Description != "" || patched.Status != model.Active.String() || patched.Name != "Bridge" || patched.AuthorID != 3 {
+		t.Errorf("patched = %+v", patched)
+	}
+
+	tests := []struct {
+		name      string
+		patch     string
+		wantField string
+	}{
+		{"merged result is validated", `{"name": null, "latitude": 100}`, "name"},
+		{"read-only field", `{"authorId": 4}`, "authorId"},
+		{"unknown field", `{"xpPoint": 5}`, "xpPoint"},
+		{"field that is not sent as JSON", `{"location": null}`, "location"},
+		{"not an object", `[1]`, ""},
+	}
+	for _, tt := range tests {
+		_, err := service.Patch(ctx, id, []byte(tt.patch))
+		fields := FieldsOf(err)
+		if KindOf(err) != Validation || (tt.wantField != "" && (len(fields) == 0 || fields[0].Field != tt.wantField)) {
+			t.Errorf("%s: Patch error = %v, fields = %+v, want %q reported", tt.name, err, fields, tt.wantField)
+		}
+	}
+
+	_, err = service.Patch(ctx, id, []byte(`{"xpPoint": 5, "nmae": "Bridge", "name": "Tower"}`))
+	want := []FieldError{{Field: "nmae", Message: "is not a known field"}, {Field: "xpPoint", Message: "is not a known field"}}
+	if got := FieldsOf(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Patch with two unknown fields reported %+v, want %+v", got, want)
+	}
+
+	if _, err := service.Patch(ctx, id, []byte(`{"status": "Draft"}`)); KindOf(err) != Conflict {
+		t.Errorf("Patch back to Draft error = %v, want a Conflict", err)
+	}
+	if _, err := service.Patch(ctx, "000000000000000000000000", []byte(`{}`)); KindOf(err) != NotFound {
+		t.Errorf("Patch of a missing encounter error = %v, want NotFound", err)
+	}
+}
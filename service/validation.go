@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"database-example/model"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// Greske nose imena polja iz JSON-a, kako ih klijent salje
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterValidation("encounterStatus", func(field validator.FieldLevel) bool {
+		_, err := model.ParseEncounterStatus(field.Field().String())
+		return err == nil
+	})
+	v.RegisterValidation("encounterType", func(field validator.FieldLevel) bool {
+		_, err := model.ParseEncounterType(field.Field().String())
+		return err == nil
+	})
+	return v
+}
+
+// ruleMessages describe the violated validate tag to the client.
+var ruleMessages = map[string]func(param string) string{
+	"required":        func(string) string { return "is required" },
+	"min":             func(param string) string { return "must be at least " + param },
+	"max":             func(param string) string { return "must be at most " + param },
+	"gt":              func(param string) string { return "must be greater than " + param },
+	"url":             func(string) string { return "must be a URL" },
+	"encounterStatus": func(string) string { return "must be Draft, Active or Archived" },
+	"encounterType":   func(string) string { return "must be Social, Location or Misc" },
+}
+
+// validatePayload checks the validate tags of the payload and returns every violation,
+// together with the violations the caller found itself, as one Validation error.
+func validatePayload(payload interface{}, fields ...FieldError) error {
+	var violations validator.ValidationErrors
+	if err := validate.Struct(payload); errors.As(err, &violations) {
+		for _, violation := range violations {
+			message := fmt.Sprintf("fails the %s rule", violation.Tag())
+			if describe, ok := ruleMessages[violation.Tag()]; ok {
+				message = describe(violation.Param())
+			}
+			fields = append(fields, FieldError{Field: fieldPath(violation.Namespace()), Message: message})
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return newError(Validation, "payload has invalid fields", fields...)
+}
+
+// fieldPath turns the namespace of a violation, such as EncounterDetails.Encounter.name,
+// into the path of the field in the JSON payload, socialEncounter.distanceTreshold for
+// a nested part. Embedded structs have no name in the JSON and are left out.
+func fieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")[1:]
+	path := segments[:0]
+	for _, segment := range segments {
+		if segment != "" && segment != "Encounter" {
+			path = append(path, segment)
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+// validatePart validates a social or hidden location part together with the
+// encounter it belongs to, which must exist and be of the part's type.
+func (s *EncounterService) validatePart(ctx context.Context, part interface{}, encounterID string, encounterType model.EncounterType) error {
+	fields, err := s.checkParentEncounter(ctx, encounterID, encounterType)
+	if err != nil {
+		return err
+	}
+	return validatePayload(part, fields...)
+}
+
+func (s *EncounterService) checkParentEncounter(ctx context.Context, encounterID string, encounterType model.EncounterType) ([]FieldError, error) {
+	if encounterID == "" {
+		return []FieldError{{Field: "encounterId", Message: "is required"}}, nil
+	}
+	encounter, err := s.EncounterRepo.GetEncounterById(ctx, encounterID)
+	if errors.Is(err, ErrEncounterNotFound) {
+		return []FieldError{{Field: "encounterId", Message: "does not name an encounter"}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if parentType, err := model.ParseEncounterType(encounter.Type); err != nil || parentType != encounterType {
+		return []FieldError{{Field: "encounterId", Message: fmt.Sprintf("must name a %s encounter", encounterType)}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"database-example/model"
+	"reflect"
+	"testing"
+)
+
+func TestValidatePayload_ReportsEveryViolation(t *testing.T) {
+	err := validatePayload(&model.EncounterDetails{
+		Encounter: model.Encounter{XpPoints: -5, Type: "Quest", Latitude: 200},
+		SocialEncounter: &model.SocialEncounter{
+			TouristsRequiredForCompletion: 0,
+			DistanceTreshold:              -1,
+		},
+	})
+	if KindOf(err) != Validation {
+		t.Fatalf("validatePayload error = %v, want a Validation error", err)
+	}
+
+	want := []FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "xpPoints", Message: "must be at least 0"},
+		{Field: "type", Message: "must be Social, Location or Misc"},
+		{Field: "latitude", Message: "must be at most 90"},
+		{Field: "socialEncounter.touristsRequiredForCompletion", Message: "must be at least 1"},
+		{Field: "socialEncounter.distanceTreshold", Message: "must be greater than 0"},
+	}
+	if got := FieldsOf(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestEncounterService_CreateSocialEncounterChecksParent(t *testing.T) {
+	ctx := context.Background()
+	service := newEncounterService()
+	misc, err := service.Create(ctx, &model.Encounter{Name: "Bridge", Type: model.Misc.String()})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	social, err := service.Create(ctx, &model.Encounter{Name: "Square", Type: model.Social.String()})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		encounterID string
+		wantMessage string
+	}{
+		{"missing", "", "is required"},
+		{"unknown", "000000000000000000000000", "does not name an encounter"},
+		{"other type", misc.ID.Hex(), "must name a Social encounter"},
+		{"matching type", social.ID.Hex(), ""},
+	}
+	for _, tt := range tests {
+		err := service.CreateSocialEncounter(ctx, &model.SocialEncounter{EncounterID: tt.encounterID, TouristsRequiredForCompletion: 2, DistanceTreshold: 50})
+		fields := FieldsOf(err)
+		if tt.wantMessage == "" {
+			if err != nil {
+				t.Errorf("%s: CreateSocialEncounter error = %v", tt.name, err)
+			}
+			continue
+		}
+		if len(fields) != 1 || fields[0] != (FieldError{Field: "encounterId", Message: tt.wantMessage}) {
+			t.Errorf("%s: CreateSocialEncounter fields = %+v, want encounterId %q", tt.name, fields, tt.wantMessage)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package tracing
+
+import (
+	"context"
+	"database-example/config"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+)
+
+// Provider is the tracer provider of the service together with the state of its exporter.
+type Provider struct {
+	*trace.TracerProvider
+	exporter *stateExporter
+}
+
+// ExporterState describes the exporter and the outcome of its last export.
+type ExporterState struct {
+	Exporter   string     `json:"exporter"`
+	LastExport *time.Time `json:"lastExport,omitempty"`
+	LastError  string     `json:"lastError,omitempty"`
+}
+
+// NewProvider creates the tracer provider with the exporter and sampler selected in cfg.
+// The version is reported as the service.version resource attribute.
+func NewProvider(cfg config.TracingConfig, version string) (*Provider, error) {
+	var exporter trace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case config.TracerOTLPGRPC:
+		exporter, err = newOTLPGRPCExporter(cfg)
+	case config.TracerOTLPHTTP:
+		exporter, err = newOTLPHTTPExporter(cfg)
+	case config.TracerFile:
+		exporter, err = newFileExporter(cfg.File)
+	case config.TracerStdout:
+		slog.Info("Initializing tracing to stdout")
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		slog.Info("Tracing is disabled")
+		return &Provider{TracerProvider: trace.NewTracerProvider(), exporter: &stateExporter{name: config.TracerNone}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("encounters-service"),
+		semconv.ServiceVersion(version),
+		semconv.DeploymentEnvironment(cfg.Environment),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	state := &stateExporter{SpanExporter: exporter, name: cfg.Exporter}
+	provider := trace.NewTracerProvider(
+		trace.WithBatcher(state),
+		trace.WithSampler(newSampler(cfg)),
+		trace.WithResource(res),
+	)
+	return &Provider{TracerProvider: provider, exporter: state}, nil
+}
+
+// newSampler records cfg.SampleRatio of new traces. A parent based sampler keeps the
+// decision of the caller, so a trace is either recorded in every service or in none.
+func newSampler(cfg config.TracingConfig) trace.Sampler {
+	sampler := trace.TraceIDRatioBased(cfg.SampleRatio)
+	if cfg.ParentBased {
+		return trace.ParentBased(sampler)
+	}
+	return sampler
+}
+
+// ExporterState returns the exporter name and the outcome of the last export.
+func (p *Provider) ExporterState() ExporterState {
+	return p.exporter.state()
+}
+
+func newFileExporter(path string) (trace.SpanExporter, error) {
+	slog.Info("Initializing tracing to a file", "path", path)
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return stdouttrace.New(
+		stdouttrace.WithWriter(f),
+		stdouttrace.WithPrettyPrint(),
+	)
+}
+
+// The OTLP exporters connect lazily, so a collector that is down does not stop the
+// service from starting; failed exports show up in ExporterState.
+func newOTLPGRPCExporter(cfg config.TracingConfig) (trace.SpanExporter, error) {
+	slog.Info("Initializing tracing to OTLP over gRPC", "endpoint", cfg.Endpoint)
+	options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(context.Background(), options...)
+}
+
+func newOTLPHTTPExporter(cfg config.TracingConfig) (trace.SpanExporter, error) {
+	slog.Info("Initializing tracing to OTLP over HTTP", "endpoint", cfg.Endpoint)
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	return otlptracehttp.New(context.Background(), options...)
+}
+
+// stateExporter remembers the outcome of the last export of the exporter it wraps.
+type stateExporter struct {
+	trace.SpanExporter
+	name string
+
+	lock       sync.Mutex
+	lastExport time.Time
+	lastErr    error
+}
+
+func (e *stateExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.lastExport = time.Now().UTC()
+	e.lastErr = err
+	return err
+}
+
+func (e *stateExporter) state() ExporterState {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	state := ExporterState{Exporter: e.name}
+	if !e.lastExport.IsZero() {
+		lastExport := e.lastExport
+		state.LastExport = &lastExport
+	}
+	if e.lastErr != nil {
+		state.LastError = e.lastErr.Error()
+	}
+	return state
+}